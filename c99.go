@@ -1,31 +1,132 @@
-package main
+// Package c99 is a client for the C99 API at https://api.c99.nl/. Every
+// endpoint has a method on C99 returning the decoded response; the *Typed
+// variants decode it further into a result struct.
+package c99
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
-	"os"
-	"reflect"
+	"strconv"
 	"strings"
+	"time"
+
+	"c99/internal/redact"
 )
 
 type C99 struct {
 	Key     string
 	BaseURL string
+	// HTTPClient is used for all requests; nil means http.DefaultClient.
+	HTTPClient *http.Client
+	// Cache, if set, serves repeated requests from earlier responses.
+	Cache Cache
+	// OnCacheError, if set, is called when storing a response in Cache
+	// fails. The call itself still succeeds.
+	OnCacheError func(err error)
+	// DryRun, if set, is handed each request instead of it being sent;
+	// the method then returns a nil result and nil error.
+	DryRun func(req *http.Request)
+	// Raw, if set, makes methods return responses as the API sent them
+	// instead of passing them through Normalize.
+	Raw bool
+	// Retries is how many times a request that failed with a network
+	// error, HTTP 429 or a 5xx status is retried. Zero disables retries.
+	Retries int
+
+	// meta, if set, is filled in with the metadata of each request; see
+	// CallMethod.
+	meta *Meta
 }
 
+// MethodInfo describes a method in the registry. Its JSON form is printed
+// by the list and describe commands.
 type MethodInfo struct {
-	Name        string
-	Description string
-	Params      []ParamInfo
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Endpoint is the C99 API endpoint the method calls.
+	Endpoint string `json:"endpoint"`
+	// Category groups related methods; see Categories.
+	Category string `json:"category"`
+	// Tags are extra keywords matched by the search command.
+	Tags   []string    `json:"tags"`
+	Params []ParamInfo `json:"params"`
+	// Examples are sample command lines, without the program name.
+	Examples []string `json:"examples"`
+	// Call invokes the method with fully resolved positional arguments,
+	// one per entry in Params.
+	Call func(ctx context.Context, c *C99, args []string, opts ...CallOption) (map[string]interface{}, error) `json:"-"`
 }
 
 type ParamInfo struct {
-	Name     string
-	Type     string
-	Required bool
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+	// Default is used when an optional parameter is omitted.
+	Default string `json:"default,omitempty"`
+	// Enum lists well-known values, offered by shell completion. Other
+	// values are still passed through to the API.
+	Enum []string `json:"values,omitempty"`
+}
+
+// APIError is returned when the API answers a request with success:false.
+// The method still returns the decoded response alongside it.
+type APIError struct {
+	Endpoint string
+	// Message is the API's "error" field.
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("c99 %s: request failed", e.Endpoint)
+	}
+	return fmt.Sprintf("c99 %s: %s", e.Endpoint, e.Message)
+}
+
+// quotaPhrases are the phrases by which API messages report that a key
+// has run out of requests, as in "You have reached your daily API limit."
+// A bare "limit" is not enough: it also appears in errors about bad
+// arguments.
+var quotaPhrases = []string{"api limit", "daily limit", "monthly limit", "request limit",
+	"rate limit", "quota", "out of credits", "no credits", "too many requests"}
+
+// QuotaExceeded reports whether the error means the key has run out of
+// requests for its plan.
+func (e *APIError) QuotaExceeded() bool {
+	msg := strings.ToLower(e.Message)
+	for _, phrase := range quotaPhrases {
+		if strings.Contains(msg, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusError is returned when the API answers a request with an HTTP
+// status other than 2xx, after any retries.
+type StatusError struct {
+	Endpoint   string
+	StatusCode int
+	Status     string
+	// Message is the "error" or "message" field of a JSON body, if any.
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("c99 %s: unexpected HTTP status %s", e.Endpoint, e.Status)
+	}
+	return fmt.Sprintf("c99 %s: unexpected HTTP status %s: %s", e.Endpoint, e.Status, e.Message)
+}
+
+// QuotaExceeded reports whether the status is 429 Too Many Requests.
+func (e *StatusError) QuotaExceeded() bool {
+	return e.StatusCode == http.StatusTooManyRequests
 }
 
 func NewC99(apikey string) *C99 {
@@ -35,7 +136,45 @@ func NewC99(apikey string) *C99 {
 	}
 }
 
-func (c *C99) makeRequest(endpoint string, params map[string]string) (map[string]interface{}, error) {
+func (c *C99) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (c *C99) makeRequest(ctx context.Context, endpoint string, params map[string]string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.request(ctx, endpoint, params, false, opts...)
+}
+
+// request calls endpoint and normalizes the response unless c.Raw is set.
+// With exact set, numbers in the result are decoded as json.Number instead
+// of float64, so that amounts keep every digit the API sent.
+func (c *C99) request(ctx context.Context, endpoint string, params map[string]string, exact bool, opts ...CallOption) (map[string]interface{}, error) {
+	body, err := c.fetch(ctx, endpoint, params, opts...)
+	if body == nil {
+		return nil, err
+	}
+	raw, derr := decodeResponse(body, exact)
+	if derr != nil {
+		return nil, derr
+	}
+	if c.Raw {
+		return raw, err
+	}
+	return normalize(requestNormalizeRule(endpoint, params), raw, exact), err
+}
+
+// fetch sends a request to endpoint and returns the response body, which
+// fetch has checked is JSON, and an *APIError if the response reports
+// failure. A non-2xx status gives a *StatusError and no body. Requests are answered from c.Cache when possible, and retried
+// as c.Retries allows, all subject to opts. In a dry run, fetch returns
+// nil and no error.
+func (c *C99) fetch(ctx context.Context, endpoint string, params map[string]string, opts ...CallOption) ([]byte, error) {
+	o := newCallOptions(opts)
+	for k, v := range o.params {
+		params[k] = v
+	}
 	params["key"] = c.Key
 	params["json"] = "true"
 
@@ -50,247 +189,356 @@ func (c *C99) makeRequest(endpoint string, params map[string]string) (map[string
 	}
 	u.RawQuery = q.Encode()
 
-	resp, err := http.Get(u.String())
+	client := c.httpClient()
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+		// The deadline replaces the client's own timeout.
+		hc := *client
+		hc.Timeout = 0
+		client = &hc
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	if c.DryRun != nil {
+		c.DryRun(req)
+		return nil, nil
+	}
+
+	m := c.meta
+	if m != nil {
+		start := time.Now()
+		*m = Meta{Endpoint: endpoint, Params: redactParams(params), KeyFingerprint: keyFingerprint(c.Key), Time: start.UTC()}
+		defer func() { m.Latency = time.Since(start) }()
+	}
 
-	body, err := ioutil.ReadAll(resp.Body)
+	key := ""
+	if c.Cache != nil && !uncachedEndpoints[endpoint] {
+		key = cacheKey(endpoint, params)
+		if body, stored, ok := c.Cache.Get(key); ok && !o.noCache && json.Valid(body) {
+			if m != nil {
+				m.CacheHit, m.CacheAge = true, time.Since(stored)
+			}
+			return body, nil
+		}
+	}
+
+	resp, body, attempts, err := c.send(req, client)
+	if m != nil {
+		m.Attempts = attempts
+		if resp != nil {
+			m.Status = resp.StatusCode
+		}
+	}
 	if err != nil {
+		// Keep the API key out of error messages.
+		if ue, ok := err.(*url.Error); ok {
+			ue.URL = redact.URL(u)
+		}
 		return nil, err
 	}
 
+	var env struct {
+		Success interface{} `json:"success"`
+		Error   interface{} `json:"error"`
+		Message interface{} `json:"message"`
+	}
+	jsonErr := json.Unmarshal(body, &env)
+	if resp.StatusCode/100 != 2 {
+		msg := asString(env.Error)
+		if msg == "" {
+			msg = asString(env.Message)
+		}
+		return nil, &StatusError{Endpoint: endpoint, StatusCode: resp.StatusCode, Status: resp.Status, Message: msg}
+	}
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	success, known := normalizeBool(env.Success).(bool)
+	if known && !success {
+		return body, &APIError{Endpoint: endpoint, Message: asString(env.Error)}
+	}
+	if key != "" && success {
+		if err := c.Cache.Set(key, body); err != nil && c.OnCacheError != nil {
+			c.OnCacheError(err)
+		}
+	}
+	return body, nil
+}
+
+// send performs req with client, retrying network errors, HTTP 429 and 5xx
+// statuses up to c.Retries times with exponential backoff, or after the
+// delay a Retry-After header asks for. It also returns how many attempts
+// were made.
+func (c *C99) send(req *http.Request, client *http.Client) (*http.Response, []byte, int, error) {
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		resp, err := client.Do(req)
+		var body []byte
+		if err == nil {
+			body, err = ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+		retry := err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if !retry || attempt > c.Retries || req.Context().Err() != nil {
+			return resp, body, attempt, err
+		}
+
+		wait := backoff
+		if resp != nil {
+			if secs, perr := strconv.Atoi(resp.Header.Get("Retry-After")); perr == nil && secs >= 0 {
+				wait = time.Duration(secs) * time.Second
+			}
+		}
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, nil, attempt, req.Context().Err()
+		}
+		backoff *= 2
+	}
+}
+
+// retryBackoff is the delay before the first retry; it doubles after
+// each attempt.
+var retryBackoff = 500 * time.Millisecond
+
+func decodeResponse(body []byte, exact bool) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if exact {
+		dec.UseNumber()
+	}
 	var result map[string]interface{}
-	err = json.Unmarshal(body, &result)
-	if err != nil {
+	if err := dec.Decode(&result); err != nil {
 		return nil, err
 	}
-
 	return result, nil
 }
 
 // GetSubDomains finds subdomains of a given domain.
-func (c *C99) GetSubDomains(subdomain string) (map[string]interface{}, error) {
-	return c.makeRequest("subdomainfinder", map[string]string{"domain": subdomain})
+func (c *C99) GetSubDomains(ctx context.Context, subdomain string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "subdomainfinder", map[string]string{"domain": subdomain}, opts...)
 }
 
 // GetPhoneInfo gets information about a phone number.
-func (c *C99) GetPhoneInfo(number string) (map[string]interface{}, error) {
-	return c.makeRequest("phonelookup", map[string]string{"number": number})
+func (c *C99) GetPhoneInfo(ctx context.Context, number string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "phonelookup", map[string]string{"number": number}, opts...)
 }
 
 // GetSkypeUserInfo gets information about a Skype user.
-func (c *C99) GetSkypeUserInfo(username string) (map[string]interface{}, error) {
-	return c.makeRequest("skyperesolver", map[string]string{"username": username})
+func (c *C99) GetSkypeUserInfo(ctx context.Context, username string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "skyperesolver", map[string]string{"username": username}, opts...)
 }
 
 // GetSkypeIPInfo gets Skype information associated with an IP address.
-func (c *C99) GetSkypeIPInfo(ip string) (map[string]interface{}, error) {
-	return c.makeRequest("ip2skype", map[string]string{"ip": ip})
+func (c *C99) GetSkypeIPInfo(ctx context.Context, ip string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "ip2skype", map[string]string{"ip": ip}, opts...)
 }
 
 // FirewallResolver detects firewalls on a given domain.
-func (c *C99) FirewallResolver(domain string) (map[string]interface{}, error) {
-	return c.makeRequest("firewalldetector", map[string]string{"url": domain})
+func (c *C99) FirewallResolver(ctx context.Context, domain string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "firewalldetector", map[string]string{"url": domain}, opts...)
 }
 
 // PortScanner scans ports on a given IP address.
-func (c *C99) PortScanner(ip string) (map[string]interface{}, error) {
-	return c.makeRequest("portscanner", map[string]string{"host": ip})
+func (c *C99) PortScanner(ctx context.Context, ip string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "portscanner", map[string]string{"host": ip}, opts...)
 }
 
 // CheckPort checks if a specific port is open on a given host.
-func (c *C99) CheckPort(host, port string) (map[string]interface{}, error) {
-	return c.makeRequest("portscanner", map[string]string{"host": host, "port": port})
+func (c *C99) CheckPort(ctx context.Context, host, port string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "portscanner", map[string]string{"host": host, "port": port}, opts...)
 }
 
 // Ping pings a given IP address.
-func (c *C99) Ping(ip string) (map[string]interface{}, error) {
-	return c.makeRequest("ping", map[string]string{"host": ip})
+func (c *C99) Ping(ctx context.Context, ip string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "ping", map[string]string{"host": ip}, opts...)
 }
 
 // HostnameResolver resolves hostname for a given IP address.
-func (c *C99) HostnameResolver(ip string) (map[string]interface{}, error) {
-	return c.makeRequest("gethostname", map[string]string{"host": ip})
+func (c *C99) HostnameResolver(ctx context.Context, ip string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "gethostname", map[string]string{"host": ip}, opts...)
 }
 
 // DNSChecker checks DNS records for a given domain.
-func (c *C99) DNSChecker(domain string) (map[string]interface{}, error) {
-	return c.makeRequest("dnschecker", map[string]string{"url": domain})
+func (c *C99) DNSChecker(ctx context.Context, domain string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "dnschecker", map[string]string{"url": domain}, opts...)
 }
 
 // HostToIP converts a hostname to an IP address.
-func (c *C99) HostToIP(host string) (map[string]interface{}, error) {
-	return c.makeRequest("dnsresolver", map[string]string{"host": host, "server": "US"})
+func (c *C99) HostToIP(ctx context.Context, host string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "dnsresolver", map[string]string{"host": host, "server": "US"}, opts...)
 }
 
 // IPToDomains finds domains associated with a given IP address.
-func (c *C99) IPToDomains(ip string) (map[string]interface{}, error) {
-	return c.makeRequest("ip2domains", map[string]string{"ip": ip})
+func (c *C99) IPToDomains(ctx context.Context, ip string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "ip2domains", map[string]string{"ip": ip}, opts...)
 }
 
 // AlexaRank gets the Alexa rank for a given URL.
-func (c *C99) AlexaRank(url string) (map[string]interface{}, error) {
-	return c.makeRequest("alexarank", map[string]string{"url": url})
+func (c *C99) AlexaRank(ctx context.Context, url string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "alexarank", map[string]string{"url": url}, opts...)
 }
 
 // WhoisChecker performs a WHOIS lookup for a given domain.
-func (c *C99) WhoisChecker(domain string) (map[string]interface{}, error) {
-	return c.makeRequest("whois", map[string]string{"domain": domain})
+func (c *C99) WhoisChecker(ctx context.Context, domain string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "whois", map[string]string{"domain": domain}, opts...)
 }
 
 // ScreenshotTool takes a screenshot of a given URL.
-func (c *C99) ScreenshotTool(url string) (map[string]interface{}, error) {
-	return c.makeRequest("createscreenshot", map[string]string{"url": url})
+func (c *C99) ScreenshotTool(ctx context.Context, url string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "createscreenshot", map[string]string{"url": url}, opts...)
 }
 
 // GeoIP gets geolocation information for a given IP address.
-func (c *C99) GeoIP(host string) (map[string]interface{}, error) {
-	return c.makeRequest("geoip", map[string]string{"host": host})
+func (c *C99) GeoIP(ctx context.Context, host string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "geoip", map[string]string{"host": host}, opts...)
 }
 
 // WebsiteUpOrDownChecker checks if a website is up or down.
-func (c *C99) WebsiteUpOrDownChecker(host string) (map[string]interface{}, error) {
-	return c.makeRequest("upordown", map[string]string{"host": host})
+func (c *C99) WebsiteUpOrDownChecker(ctx context.Context, host string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "upordown", map[string]string{"host": host}, opts...)
 }
 
 // SiteReputationChecker checks the reputation of a given URL.
-func (c *C99) SiteReputationChecker(url string) (map[string]interface{}, error) {
-	return c.makeRequest("reputationchecker", map[string]string{"url": url})
+func (c *C99) SiteReputationChecker(ctx context.Context, url string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "reputationchecker", map[string]string{"url": url}, opts...)
 }
 
 // GetWebsiteHeaders gets HTTP headers for a given website.
-func (c *C99) GetWebsiteHeaders(host string) (map[string]interface{}, error) {
-	return c.makeRequest("getheaders", map[string]string{"host": host})
+func (c *C99) GetWebsiteHeaders(ctx context.Context, host string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "getheaders", map[string]string{"host": host}, opts...)
 }
 
 // LinkBackup creates a backup of a given URL.
-func (c *C99) LinkBackup(url string) (map[string]interface{}, error) {
-	return c.makeRequest("linkbackup", map[string]string{"url": url})
+func (c *C99) LinkBackup(ctx context.Context, url string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "linkbackup", map[string]string{"url": url}, opts...)
 }
 
 // URLShortener shortens a given URL.
-func (c *C99) URLShortener(url string) (map[string]interface{}, error) {
-	return c.makeRequest("urlshortener", map[string]string{"url": url})
+func (c *C99) URLShortener(ctx context.Context, url string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "urlshortener", map[string]string{"url": url}, opts...)
 }
 
 // RandomStringPicker picks a random string from a given text file.
-func (c *C99) RandomStringPicker(textfile string) (map[string]interface{}, error) {
-	return c.makeRequest("randomstringpicker", map[string]string{"textfile": textfile})
+func (c *C99) RandomStringPicker(ctx context.Context, textfile string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "randomstringpicker", map[string]string{"textfile": textfile}, opts...)
 }
 
 // Dictionary looks up the definition of a word.
-func (c *C99) Dictionary(word string) (map[string]interface{}, error) {
-	return c.makeRequest("dictionary", map[string]string{"word": word})
+func (c *C99) Dictionary(ctx context.Context, word string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "dictionary", map[string]string{"word": word}, opts...)
 }
 
 // ImageReverse performs a reverse image search.
-func (c *C99) ImageReverse(url string) (map[string]interface{}, error) {
-	return c.makeRequest("definepicture", map[string]string{"url": url})
+func (c *C99) ImageReverse(ctx context.Context, url string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "definepicture", map[string]string{"url": url}, opts...)
 }
 
 // SynonymFinder finds synonyms for a given word.
-func (c *C99) SynonymFinder(word string) (map[string]interface{}, error) {
-	return c.makeRequest("synonym", map[string]string{"word": word})
+func (c *C99) SynonymFinder(ctx context.Context, word string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "synonym", map[string]string{"word": word}, opts...)
 }
 
 // EmailValidator validates an email address.
-func (c *C99) EmailValidator(email string) (map[string]interface{}, error) {
-	return c.makeRequest("emailvalidator", map[string]string{"email": email})
+func (c *C99) EmailValidator(ctx context.Context, email string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "emailvalidator", map[string]string{"email": email}, opts...)
 }
 
 // DisposableMailCheck checks if an email is from a disposable email service.
-func (c *C99) DisposableMailCheck(email string) (map[string]interface{}, error) {
-	return c.makeRequest("disposablemailchecker", map[string]string{"email": email})
+func (c *C99) DisposableMailCheck(ctx context.Context, email string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "disposablemailchecker", map[string]string{"email": email}, opts...)
 }
 
 // IPValidator validates an IP address.
-func (c *C99) IPValidator(ip string) (map[string]interface{}, error) {
-	return c.makeRequest("ipvalidator", map[string]string{"ip": ip})
+func (c *C99) IPValidator(ctx context.Context, ip string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "ipvalidator", map[string]string{"ip": ip}, opts...)
 }
 
 // TorChecker checks if an IP address is a Tor exit node.
-func (c *C99) TorChecker(ip string) (map[string]interface{}, error) {
-	return c.makeRequest("torchecker", map[string]string{"ip": ip})
+func (c *C99) TorChecker(ctx context.Context, ip string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "torchecker", map[string]string{"ip": ip}, opts...)
 }
 
 // Translator translates text to a specified language.
-func (c *C99) Translator(text, tolanguage string) (map[string]interface{}, error) {
-	return c.makeRequest("translate", map[string]string{"text": text, "tolanguage": tolanguage})
+func (c *C99) Translator(ctx context.Context, text, tolanguage string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "translate", map[string]string{"text": text, "tolanguage": tolanguage}, opts...)
 }
 
 // RandomInfoGenerator generates random person information.
-func (c *C99) RandomInfoGenerator(gender string) (map[string]interface{}, error) {
-	return c.makeRequest("randomperson", map[string]string{"gender": gender})
+func (c *C99) RandomInfoGenerator(ctx context.Context, gender string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "randomperson", map[string]string{"gender": gender}, opts...)
 }
 
 // YouTubeVideoDetails gets details about a YouTube video.
-func (c *C99) YouTubeVideoDetails(videoid string) (map[string]interface{}, error) {
-	return c.makeRequest("youtubedetails", map[string]string{"videoid": videoid})
+func (c *C99) YouTubeVideoDetails(ctx context.Context, videoid string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "youtubedetails", map[string]string{"videoid": videoid}, opts...)
 }
 
 // YouTubeToMP3 converts a YouTube video to MP3.
-func (c *C99) YouTubeToMP3(videoid string) (map[string]interface{}, error) {
-	return c.makeRequest("youtubemp3", map[string]string{"videoid": videoid})
+func (c *C99) YouTubeToMP3(ctx context.Context, videoid string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "youtubemp3", map[string]string{"videoid": videoid}, opts...)
 }
 
 // IPLogger logs IP addresses.
-func (c *C99) IPLogger(action string) (map[string]interface{}, error) {
-	return c.makeRequest("iplogger", map[string]string{"action": action})
+func (c *C99) IPLogger(ctx context.Context, action string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "iplogger", map[string]string{"action": action}, opts...)
 }
 
 // BitcoinBalance checks the balance of a Bitcoin address.
-func (c *C99) BitcoinBalance(address string) (map[string]interface{}, error) {
-	return c.makeRequest("bitcoinbalance", map[string]string{"address": address})
+func (c *C99) BitcoinBalance(ctx context.Context, address string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "bitcoinbalance", map[string]string{"address": address}, opts...)
 }
 
 // EthereumBalance checks the balance of an Ethereum address.
-func (c *C99) EthereumBalance(address string) (map[string]interface{}, error) {
-	return c.makeRequest("ethereumbalance", map[string]string{"address": address})
+func (c *C99) EthereumBalance(ctx context.Context, address string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "ethereumbalance", map[string]string{"address": address}, opts...)
 }
 
 // CurrencyConverter converts between currencies.
-func (c *C99) CurrencyConverter(amount, fromCurrency, toCurrency string) (map[string]interface{}, error) {
-	return c.makeRequest("currency", map[string]string{"amount": amount, "from": fromCurrency, "to": toCurrency})
+func (c *C99) CurrencyConverter(ctx context.Context, amount, fromCurrency, toCurrency string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "currency", map[string]string{"amount": amount, "from": fromCurrency, "to": toCurrency}, opts...)
 }
 
 // CurrencyRates gets current currency exchange rates.
-func (c *C99) CurrencyRates(source string) (map[string]interface{}, error) {
-	return c.makeRequest("currencyrates", map[string]string{"source": source})
+func (c *C99) CurrencyRates(ctx context.Context, source string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "currencyrates", map[string]string{"source": source}, opts...)
 }
 
 // WeatherChecker checks the weather for a given location.
-func (c *C99) WeatherChecker(location, unit string) (map[string]interface{}, error) {
-	return c.makeRequest("weather", map[string]string{"location": location, "unit": unit})
+func (c *C99) WeatherChecker(ctx context.Context, location, unit string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "weather", map[string]string{"location": location, "unit": unit}, opts...)
 }
 
 // QRCodeGenerator generates a QR code.
-func (c *C99) QRCodeGenerator(str string, size string) (map[string]interface{}, error) {
-	return c.makeRequest("qrgenerator", map[string]string{"string": str, "size": size})
+func (c *C99) QRCodeGenerator(ctx context.Context, str string, size string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "qrgenerator", map[string]string{"string": str, "size": size}, opts...)
 }
 
 // TextParser parses text from a given URL.
-func (c *C99) TextParser(url string) (map[string]interface{}, error) {
-	return c.makeRequest("textparser", map[string]string{"url": url})
+func (c *C99) TextParser(ctx context.Context, url string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "textparser", map[string]string{"url": url}, opts...)
 }
 
 // ProxyDetector detects if an IP address is a proxy.
-func (c *C99) ProxyDetector(ip string) (map[string]interface{}, error) {
-	return c.makeRequest("proxydetector", map[string]string{"ip": ip})
+func (c *C99) ProxyDetector(ctx context.Context, ip string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "proxydetector", map[string]string{"ip": ip}, opts...)
 }
 
 // PasswordGenerator generates a random password.
-func (c *C99) PasswordGenerator(length, include, customlist string) (map[string]interface{}, error) {
-	return c.makeRequest("passwordgenerator", map[string]string{
+func (c *C99) PasswordGenerator(ctx context.Context, length, include, customlist string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "passwordgenerator", map[string]string{
 		"length":     length,
 		"include":    include,
 		"customlist": customlist,
-	})
+	}, opts...)
 }
 
 // RandomNumberGenerator generates a random number.
-func (c *C99) RandomNumberGenerator(length, between string) (map[string]interface{}, error) {
+func (c *C99) RandomNumberGenerator(ctx context.Context, length, between string, opts ...CallOption) (map[string]interface{}, error) {
 	params := make(map[string]string)
 	if length != "" {
 		params["length"] = length
@@ -298,454 +546,900 @@ func (c *C99) RandomNumberGenerator(length, between string) (map[string]interfac
 	if between != "" {
 		params["between"] = between
 	}
-	return c.makeRequest("randomnumber", params)
+	return c.makeRequest(ctx, "randomnumber", params, opts...)
 }
 
 // LicenseKeyGenerator generates license keys.
-func (c *C99) LicenseKeyGenerator(template string, amount string) (map[string]interface{}, error) {
-	return c.makeRequest("licensekeygenerator", map[string]string{
+func (c *C99) LicenseKeyGenerator(ctx context.Context, template string, amount string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "licensekeygenerator", map[string]string{
 		"template": template,
 		"amount":   amount,
-	})
+	}, opts...)
 }
 
 // EitherOr gets a random 'either/or' question.
-func (c *C99) EitherOr() (map[string]interface{}, error) {
-	return c.makeRequest("eitheror", map[string]string{})
+func (c *C99) EitherOr(ctx context.Context, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "eitheror", map[string]string{}, opts...)
 }
 
 // GIFFinder finds a GIF based on a keyword.
-func (c *C99) GIFFinder(keyword string) (map[string]interface{}, error) {
-	return c.makeRequest("gif", map[string]string{"keyword": keyword})
+func (c *C99) GIFFinder(ctx context.Context, keyword string, opts ...CallOption) (map[string]interface{}, error) {
+	return c.makeRequest(ctx, "gif", map[string]string{"keyword": keyword}, opts...)
+}
+
+// currencyCodes are the common ISO 4217 codes offered for currency
+// parameters.
+var currencyCodes = []string{
+	"AED", "AUD", "BRL", "CAD", "CHF", "CNY", "CZK", "DKK", "EUR", "GBP",
+	"HKD", "HUF", "IDR", "ILS", "INR", "JPY", "KRW", "MXN", "MYR", "NOK",
+	"NZD", "PHP", "PLN", "RUB", "SAR", "SEK", "SGD", "THB", "TRY", "USD",
+	"ZAR",
+}
+
+// methodCategories lists the method categories in the order the list
+// command shows them, with their headings.
+var methodCategories = []struct{ Name, Title string }{
+	{"recon", "Domain and network reconnaissance"},
+	{"osint", "People and accounts"},
+	{"validation", "Validation and reputation"},
+	{"web", "Web tools"},
+	{"media", "Images and video"},
+	{"text", "Language"},
+	{"finance", "Finance"},
+	{"generators", "Generators"},
+	{"fun", "Fun"},
+	{"misc", "Miscellaneous"},
 }
 
-// Define method information using struct tags
 var methodInfos = []MethodInfo{
 	{
 		Name:        "GetSubDomains",
+		Endpoint:    "subdomainfinder",
 		Description: "Find subdomains of a given domain.",
+		Category:    "recon",
+		Tags:        []string{"dns", "domain", "subdomain"},
 		Params: []ParamInfo{
 			{Name: "subdomain", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"GetSubDomains example.com",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.GetSubDomains(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "GetPhoneInfo",
+		Endpoint:    "phonelookup",
 		Description: "Get information about a phone number.",
+		Category:    "osint",
+		Tags:        []string{"phone", "carrier"},
 		Params: []ParamInfo{
 			{Name: "number", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"GetPhoneInfo +31612345678",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.GetPhoneInfo(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "GetSkypeUserInfo",
+		Endpoint:    "skyperesolver",
 		Description: "Get information about a Skype user.",
+		Category:    "osint",
+		Tags:        []string{"skype", "username"},
 		Params: []ParamInfo{
 			{Name: "username", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"GetSkypeUserInfo echo123",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.GetSkypeUserInfo(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "GetSkypeIPInfo",
+		Endpoint:    "ip2skype",
 		Description: "Get Skype information associated with an IP address.",
+		Category:    "osint",
+		Tags:        []string{"skype", "ip"},
 		Params: []ParamInfo{
 			{Name: "ip", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"GetSkypeIPInfo 203.0.113.7",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.GetSkypeIPInfo(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "FirewallResolver",
+		Endpoint:    "firewalldetector",
 		Description: "Detect firewalls on a given domain.",
+		Category:    "recon",
+		Tags:        []string{"waf", "firewall", "cloudflare", "domain"},
 		Params: []ParamInfo{
 			{Name: "domain", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"FirewallResolver example.com",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.FirewallResolver(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "PortScanner",
+		Endpoint:    "portscanner",
 		Description: "Scan ports on a given IP address.",
+		Category:    "recon",
+		Tags:        []string{"ports", "scan", "ip"},
 		Params: []ParamInfo{
 			{Name: "ip", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"PortScanner 203.0.113.7",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.PortScanner(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "CheckPort",
+		Endpoint:    "portscanner",
 		Description: "Check if a specific port is open on a given host.",
+		Category:    "recon",
+		Tags:        []string{"ports", "tcp"},
 		Params: []ParamInfo{
 			{Name: "host", Type: "string", Required: true},
 			{Name: "port", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"CheckPort example.com 443",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.CheckPort(ctx, a[0], a[1], opts...)
+		},
 	},
 	{
 		Name:        "Ping",
+		Endpoint:    "ping",
 		Description: "Ping a given IP address.",
+		Category:    "recon",
+		Tags:        []string{"icmp", "latency", "ip"},
 		Params: []ParamInfo{
 			{Name: "ip", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"Ping 1.1.1.1",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.Ping(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "HostnameResolver",
+		Endpoint:    "gethostname",
 		Description: "Resolve hostname for a given IP address.",
+		Category:    "recon",
+		Tags:        []string{"ip", "reverse-dns", "ptr"},
 		Params: []ParamInfo{
 			{Name: "ip", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"HostnameResolver 8.8.8.8",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.HostnameResolver(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "DNSChecker",
+		Endpoint:    "dnschecker",
 		Description: "Check DNS records for a given domain.",
+		Category:    "recon",
+		Tags:        []string{"dns", "records", "domain"},
 		Params: []ParamInfo{
 			{Name: "domain", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"DNSChecker example.com",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.DNSChecker(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "HostToIP",
+		Endpoint:    "dnsresolver",
 		Description: "Convert a hostname to an IP address.",
+		Category:    "recon",
+		Tags:        []string{"dns", "resolve", "ip"},
 		Params: []ParamInfo{
 			{Name: "host", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"HostToIP example.com",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.HostToIP(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "IPToDomains",
+		Endpoint:    "ip2domains",
 		Description: "Find domains associated with a given IP address.",
+		Category:    "recon",
+		Tags:        []string{"reverse-ip", "domain", "ip"},
 		Params: []ParamInfo{
 			{Name: "ip", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"IPToDomains 93.184.216.34",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.IPToDomains(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "AlexaRank",
+		Endpoint:    "alexarank",
 		Description: "Get the Alexa rank for a given URL.",
+		Category:    "recon",
+		Tags:        []string{"ranking", "traffic", "seo"},
 		Params: []ParamInfo{
 			{Name: "url", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"AlexaRank https://example.com",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.AlexaRank(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "WhoisChecker",
+		Endpoint:    "whois",
 		Description: "Perform a WHOIS lookup for a given domain.",
+		Category:    "recon",
+		Tags:        []string{"whois", "registrar", "domain"},
 		Params: []ParamInfo{
 			{Name: "domain", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"WhoisChecker example.com",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.WhoisChecker(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "ScreenshotTool",
+		Endpoint:    "createscreenshot",
 		Description: "Take a screenshot of a given URL.",
+		Category:    "web",
+		Tags:        []string{"screenshot", "image", "browser"},
 		Params: []ParamInfo{
 			{Name: "url", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"ScreenshotTool https://example.com",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.ScreenshotTool(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "GeoIP",
+		Endpoint:    "geoip",
 		Description: "Get geolocation information for a given IP address.",
+		Category:    "recon",
+		Tags:        []string{"geolocation", "country", "ip"},
 		Params: []ParamInfo{
 			{Name: "host", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"GeoIP 8.8.8.8",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.GeoIP(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "WebsiteUpOrDownChecker",
+		Endpoint:    "upordown",
 		Description: "Check if a website is up or down.",
+		Category:    "recon",
+		Tags:        []string{"uptime", "monitoring", "http"},
 		Params: []ParamInfo{
 			{Name: "host", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"WebsiteUpOrDownChecker example.com",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.WebsiteUpOrDownChecker(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "SiteReputationChecker",
+		Endpoint:    "reputationchecker",
 		Description: "Check the reputation of a given URL.",
+		Category:    "validation",
+		Tags:        []string{"reputation", "malware", "phishing", "url"},
 		Params: []ParamInfo{
 			{Name: "url", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"SiteReputationChecker https://example.com",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.SiteReputationChecker(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "GetWebsiteHeaders",
+		Endpoint:    "getheaders",
 		Description: "Get HTTP headers for a given website.",
+		Category:    "recon",
+		Tags:        []string{"http", "headers"},
 		Params: []ParamInfo{
 			{Name: "host", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"GetWebsiteHeaders https://example.com",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.GetWebsiteHeaders(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "LinkBackup",
+		Endpoint:    "linkbackup",
 		Description: "Create a backup of a given URL.",
+		Category:    "web",
+		Tags:        []string{"archive", "backup", "url"},
 		Params: []ParamInfo{
 			{Name: "url", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"LinkBackup https://example.com/page",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.LinkBackup(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "URLShortener",
+		Endpoint:    "urlshortener",
 		Description: "Shorten a given URL.",
+		Category:    "web",
+		Tags:        []string{"short-link", "url"},
 		Params: []ParamInfo{
 			{Name: "url", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"URLShortener https://example.com/a/long/path",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.URLShortener(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "RandomStringPicker",
+		Endpoint:    "randomstringpicker",
 		Description: "Pick a random string from a given text file.",
+		Category:    "fun",
+		Tags:        []string{"random", "text"},
 		Params: []ParamInfo{
 			{Name: "textfile", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"RandomStringPicker https://example.com/names.txt",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.RandomStringPicker(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "Dictionary",
+		Endpoint:    "dictionary",
 		Description: "Look up the definition of a word.",
+		Category:    "text",
+		Tags:        []string{"definition", "word", "english"},
 		Params: []ParamInfo{
 			{Name: "word", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"Dictionary serendipity",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.Dictionary(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "ImageReverse",
+		Endpoint:    "definepicture",
 		Description: "Perform a reverse image search.",
+		Category:    "media",
+		Tags:        []string{"image", "reverse-search"},
 		Params: []ParamInfo{
 			{Name: "url", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"ImageReverse https://example.com/cat.jpg",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.ImageReverse(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "SynonymFinder",
+		Endpoint:    "synonym",
 		Description: "Find synonyms for a given word.",
+		Category:    "text",
+		Tags:        []string{"synonyms", "word", "thesaurus"},
 		Params: []ParamInfo{
 			{Name: "word", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"SynonymFinder quick",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.SynonymFinder(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "EmailValidator",
+		Endpoint:    "emailvalidator",
 		Description: "Validate an email address.",
+		Category:    "validation",
+		Tags:        []string{"email", "mx", "smtp"},
 		Params: []ParamInfo{
 			{Name: "email", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"EmailValidator user@example.com",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.EmailValidator(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "DisposableMailCheck",
+		Endpoint:    "disposablemailchecker",
 		Description: "Check if an email is from a disposable email service.",
+		Category:    "validation",
+		Tags:        []string{"email", "disposable", "spam"},
 		Params: []ParamInfo{
 			{Name: "email", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"DisposableMailCheck user@mailinator.com",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.DisposableMailCheck(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "IPValidator",
+		Endpoint:    "ipvalidator",
 		Description: "Validate an IP address.",
+		Category:    "validation",
+		Tags:        []string{"ip", "format"},
 		Params: []ParamInfo{
 			{Name: "ip", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"IPValidator 192.168.1.300",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.IPValidator(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "TorChecker",
+		Endpoint:    "torchecker",
 		Description: "Check if an IP address is a Tor exit node.",
+		Category:    "validation",
+		Tags:        []string{"tor", "anonymity", "ip"},
 		Params: []ParamInfo{
 			{Name: "ip", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"TorChecker 185.220.101.1",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.TorChecker(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "Translator",
+		Endpoint:    "translate",
 		Description: "Translate text to a specified language.",
+		Category:    "text",
+		Tags:        []string{"translation", "language"},
 		Params: []ParamInfo{
 			{Name: "text", Type: "string", Required: true},
 			{Name: "tolanguage", Type: "string", Required: true},
 		},
+		Examples: []string{
+			`Translator "good morning" nl`,
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.Translator(ctx, a[0], a[1], opts...)
+		},
 	},
 	{
 		Name:        "RandomInfoGenerator",
+		Endpoint:    "randomperson",
 		Description: "Generate random person information.",
+		Category:    "generators",
+		Tags:        []string{"random", "fake-identity", "test-data"},
 		Params: []ParamInfo{
-			{Name: "gender", Type: "string", Required: true},
+			{Name: "gender", Type: "string", Required: false, Default: "all", Enum: []string{"all", "male", "female"}},
+		},
+		Examples: []string{
+			"RandomInfoGenerator",
+			"RandomInfoGenerator --gender female",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.RandomInfoGenerator(ctx, a[0], opts...)
 		},
 	},
 	{
 		Name:        "YouTubeVideoDetails",
+		Endpoint:    "youtubedetails",
 		Description: "Get details about a YouTube video.",
+		Category:    "media",
+		Tags:        []string{"youtube", "video"},
 		Params: []ParamInfo{
 			{Name: "videoid", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"YouTubeVideoDetails dQw4w9WgXcQ",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.YouTubeVideoDetails(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "YouTubeToMP3",
+		Endpoint:    "youtubemp3",
 		Description: "Convert a YouTube video to MP3.",
+		Category:    "media",
+		Tags:        []string{"youtube", "audio", "mp3"},
 		Params: []ParamInfo{
 			{Name: "videoid", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"YouTubeToMP3 dQw4w9WgXcQ",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.YouTubeToMP3(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "IPLogger",
+		Endpoint:    "iplogger",
 		Description: "Log IP addresses.",
+		Category:    "web",
+		Tags:        []string{"tracking", "ip", "logger"},
 		Params: []ParamInfo{
-			{Name: "action", Type: "string", Required: true},
+			{Name: "action", Type: "string", Required: false, Default: "viewloggers", Enum: []string{"viewloggers", "createlogger", "deletelogger", "viewlogs"}},
+		},
+		Examples: []string{
+			"IPLogger",
+			"IPLogger --action createlogger",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.IPLogger(ctx, a[0], opts...)
 		},
 	},
 	{
 		Name:        "BitcoinBalance",
+		Endpoint:    "bitcoinbalance",
 		Description: "Check the balance of a Bitcoin address.",
+		Category:    "finance",
+		Tags:        []string{"bitcoin", "crypto", "wallet"},
 		Params: []ParamInfo{
 			{Name: "address", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"BitcoinBalance 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.BitcoinBalance(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "EthereumBalance",
+		Endpoint:    "ethereumbalance",
 		Description: "Check the balance of an Ethereum address.",
+		Category:    "finance",
+		Tags:        []string{"ethereum", "crypto", "wallet"},
 		Params: []ParamInfo{
 			{Name: "address", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"EthereumBalance 0xde0B295669a9FD93d5F28D9Ec85E40f4cb697BAe",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.EthereumBalance(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "CurrencyConverter",
+		Endpoint:    "currency",
 		Description: "Convert between currencies.",
+		Category:    "finance",
+		Tags:        []string{"currency", "exchange", "forex"},
 		Params: []ParamInfo{
 			{Name: "amount", Type: "string", Required: true},
-			{Name: "fromCurrency", Type: "string", Required: true},
-			{Name: "toCurrency", Type: "string", Required: true},
+			{Name: "fromCurrency", Type: "string", Required: true, Enum: currencyCodes},
+			{Name: "toCurrency", Type: "string", Required: true, Enum: currencyCodes},
+		},
+		Examples: []string{
+			"CurrencyConverter 10 USD EUR",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.CurrencyConverter(ctx, a[0], a[1], a[2], opts...)
 		},
 	},
 	{
 		Name:        "CurrencyRates",
+		Endpoint:    "currencyrates",
 		Description: "Get current currency exchange rates.",
+		Category:    "finance",
+		Tags:        []string{"currency", "exchange", "forex"},
 		Params: []ParamInfo{
-			{Name: "source", Type: "string", Required: true},
+			{Name: "source", Type: "string", Required: true, Enum: currencyCodes},
+		},
+		Examples: []string{
+			"CurrencyRates USD",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.CurrencyRates(ctx, a[0], opts...)
 		},
 	},
 	{
 		Name:        "WeatherChecker",
+		Endpoint:    "weather",
 		Description: "Check the weather for a given location.",
+		Category:    "misc",
+		Tags:        []string{"weather", "forecast"},
 		Params: []ParamInfo{
 			{Name: "location", Type: "string", Required: true},
-			{Name: "unit", Type: "string", Required: true},
+			{Name: "unit", Type: "string", Required: false, Default: "C", Enum: []string{"C", "F"}},
+		},
+		Examples: []string{
+			"WeatherChecker Amsterdam",
+			`WeatherChecker "New York" --unit F`,
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.WeatherChecker(ctx, a[0], a[1], opts...)
 		},
 	},
 	{
 		Name:        "QRCodeGenerator",
+		Endpoint:    "qrgenerator",
 		Description: "Generate a QR code.",
+		Category:    "media",
+		Tags:        []string{"qr", "image", "barcode"},
 		Params: []ParamInfo{
 			{Name: "str", Type: "string", Required: true},
-			{Name: "size", Type: "string", Required: true},
+			{Name: "size", Type: "string", Required: false, Default: "150"},
+		},
+		Examples: []string{
+			"QRCodeGenerator https://example.com --size 300",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.QRCodeGenerator(ctx, a[0], a[1], opts...)
 		},
 	},
 	{
 		Name:        "TextParser",
+		Endpoint:    "textparser",
 		Description: "Parse text from a given URL.",
+		Category:    "web",
+		Tags:        []string{"scraping", "text", "url"},
 		Params: []ParamInfo{
 			{Name: "url", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"TextParser https://example.com/article",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.TextParser(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "ProxyDetector",
+		Endpoint:    "proxydetector",
 		Description: "Detect if an IP address is a proxy.",
+		Category:    "validation",
+		Tags:        []string{"proxy", "vpn", "ip"},
 		Params: []ParamInfo{
 			{Name: "ip", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"ProxyDetector 203.0.113.7",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.ProxyDetector(ctx, a[0], opts...)
+		},
 	},
 	{
 		Name:        "PasswordGenerator",
+		Endpoint:    "passwordgenerator",
 		Description: "Generate a random password.",
+		Category:    "generators",
+		Tags:        []string{"password", "security", "random"},
 		Params: []ParamInfo{
 			{Name: "length", Type: "string", Required: true},
 			{Name: "include", Type: "string", Required: true},
 			{Name: "customlist", Type: "string", Required: true},
 		},
+		Examples: []string{
+			`PasswordGenerator 16 upper,lower,numbers ""`,
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.PasswordGenerator(ctx, a[0], a[1], a[2], opts...)
+		},
 	},
 	{
 		Name:        "RandomNumberGenerator",
+		Endpoint:    "randomnumber",
 		Description: "Generate a random number.",
+		Category:    "generators",
+		Tags:        []string{"random", "number"},
 		Params: []ParamInfo{
 			{Name: "length", Type: "string", Required: false},
 			{Name: "between", Type: "string", Required: false},
 		},
+		Examples: []string{
+			"RandomNumberGenerator --between 1-100",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.RandomNumberGenerator(ctx, a[0], a[1], opts...)
+		},
 	},
 	{
 		Name:        "LicenseKeyGenerator",
+		Endpoint:    "licensekeygenerator",
 		Description: "Generate license keys.",
+		Category:    "generators",
+		Tags:        []string{"license", "key", "random"},
 		Params: []ParamInfo{
 			{Name: "template", Type: "string", Required: true},
-			{Name: "amount", Type: "string", Required: true},
+			{Name: "amount", Type: "string", Required: false, Default: "1"},
+		},
+		Examples: []string{
+			"LicenseKeyGenerator XXXX-XXXX-XXXX --amount 5",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.LicenseKeyGenerator(ctx, a[0], a[1], opts...)
 		},
 	},
 	{
 		Name:        "EitherOr",
+		Endpoint:    "eitheror",
 		Description: "Get a random 'either/or' question.",
+		Category:    "fun",
+		Tags:        []string{"question", "random"},
 		Params:      []ParamInfo{},
+		Examples: []string{
+			"EitherOr",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.EitherOr(ctx, opts...)
+		},
 	},
 	{
 		Name:        "GIFFinder",
+		Endpoint:    "gif",
 		Description: "Find a GIF based on a keyword.",
+		Category:    "fun",
+		Tags:        []string{"gif", "image", "giphy"},
 		Params: []ParamInfo{
 			{Name: "keyword", Type: "string", Required: true},
 		},
+		Examples: []string{
+			"GIFFinder cats",
+		},
+		Call: func(ctx context.Context, c *C99, a []string, opts ...CallOption) (map[string]interface{}, error) {
+			return c.GIFFinder(ctx, a[0], opts...)
+		},
 	},
 }
 
-func getMethodInfo(name string) *MethodInfo {
-	for _, info := range methodInfos {
-		if info.Name == name {
-			return &info
-		}
+// Methods returns the registry, in the order the list command shows it.
+// The entries are shared and must not be modified.
+func Methods() []*MethodInfo {
+	infos := make([]*MethodInfo, len(methodInfos))
+	for i := range methodInfos {
+		infos[i] = &methodInfos[i]
 	}
-	return nil
+	return infos
 }
 
-func printMethodUsage(info *MethodInfo) {
-	fmt.Printf("Usage: %s ", info.Name)
-	for _, param := range info.Params {
-		if param.Required {
-			fmt.Printf("<%s> ", param.Name)
-		} else {
-			fmt.Printf("[%s] ", param.Name)
-		}
-	}
-	fmt.Printf("\nDescription: %s\n", info.Description)
-	fmt.Println("Parameters:")
-	for _, param := range info.Params {
-		req := "optional"
-		if param.Required {
-			req = "required"
-		}
-		fmt.Printf("  %s (%s): %s\n", param.Name, param.Type, req)
-	}
+// Category is a group of related methods.
+type Category struct {
+	Name  string
+	Title string
 }
 
-func main() {
-	if len(os.Args) < 3 {
-		fmt.Println("Usage: go run c99_api.go <apikey> <method> [args...]")
-		fmt.Println("Use 'list' as the method to see all available methods")
-		os.Exit(1)
+// Categories returns the method categories in the order the list command
+// shows them.
+func Categories() []Category {
+	cats := make([]Category, len(methodCategories))
+	for i, cat := range methodCategories {
+		cats[i] = Category(cat)
 	}
+	return cats
+}
 
-	apiKey := os.Args[1]
-	method := os.Args[2]
-	args := os.Args[3:]
-
-	c99 := NewC99(apiKey)
+// FoldName normalizes a method, parameter or flag name so that
+// "fromCurrency", "from-currency" and "from_currency" all compare equal.
+func FoldName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.ReplaceAll(name, "-", "")
+	return strings.ReplaceAll(name, "_", "")
+}
 
-	if method == "list" {
-		fmt.Println("Available methods:")
-		for _, info := range methodInfos {
-			fmt.Printf("  %s: %s\n", info.Name, info.Description)
+// LookupMethod looks up a method by name. Besides the exact Go name it
+// accepts any casing, kebab-case and snake_case spellings (so the Python
+// client's method names work) and the raw C99 endpoint name.
+func LookupMethod(name string) *MethodInfo {
+	for i := range methodInfos {
+		if methodInfos[i].Name == name {
+			return &methodInfos[i]
 		}
-		os.Exit(0)
-	}
-
-	methodInfo := getMethodInfo(method)
-	if methodInfo == nil {
-		fmt.Printf("Error: Method '%s' not found\n", method)
-		fmt.Println("Use 'list' to see all available methods")
-		os.Exit(1)
-	}
-
-	if len(args) < len(methodInfo.Params) {
-		fmt.Printf("Error: Not enough arguments for method '%s'\n", method)
-		printMethodUsage(methodInfo)
-		os.Exit(1)
 	}
-
-	m := reflect.ValueOf(c99).MethodByName(method)
-	if !m.IsValid() {
-		fmt.Printf("Error: Method '%s' not found\n", method)
-		fmt.Println("Use 'list' to see all available methods")
-		os.Exit(1)
+	key := FoldName(name)
+	for i := range methodInfos {
+		if FoldName(methodInfos[i].Name) == key {
+			return &methodInfos[i]
+		}
 	}
-
-	params := make([]reflect.Value, len(args))
-	for i, arg := range args {
-		params[i] = reflect.ValueOf(arg)
+	for i := range methodInfos {
+		if methodInfos[i].Endpoint == key {
+			return &methodInfos[i]
+		}
 	}
+	return nil
+}
 
-	result := m.Call(params)
-	if len(result) == 2 && !result[1].IsNil() {
-		fmt.Printf("Error: %v\n", result[1].Interface())
-		os.Exit(1)
+// ResolveArgs maps positional and named arguments onto the method's
+// parameters. Positional arguments fill the parameters not given by name,
+// in declaration order. Parameters left over take their value from
+// defaults, such as a profile's per-method settings, and then from the
+// registry.
+func (info *MethodInfo) ResolveArgs(positional []string, named, defaults map[string]string) ([]string, error) {
+	resolved := make([]string, len(info.Params))
+	for i, param := range info.Params {
+		if value, ok := named[param.Name]; ok {
+			resolved[i] = value
+			continue
+		}
+		switch {
+		case len(positional) > 0:
+			resolved[i] = positional[0]
+			positional = positional[1:]
+		case defaults[param.Name] != "":
+			resolved[i] = defaults[param.Name]
+		case param.Required:
+			return nil, fmt.Errorf("missing required parameter '%s' for method '%s'", param.Name, info.Name)
+		default:
+			resolved[i] = param.Default
+		}
 	}
-
-	jsonResult, err := json.MarshalIndent(result[0].Interface(), "", "  ")
-	if err != nil {
-		fmt.Printf("Error marshaling JSON: %v\n", err)
-		os.Exit(1)
+	if len(positional) > 0 {
+		return nil, fmt.Errorf("too many arguments for method '%s'", info.Name)
 	}
-
-	fmt.Println(string(jsonResult))
+	return resolved, nil
 }
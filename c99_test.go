@@ -0,0 +1,58 @@
+package c99
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestHTTPStatusError(t *testing.T) {
+	for _, tc := range []struct {
+		status int
+		body   string
+		msg    string
+		quota  bool
+	}{
+		{http.StatusServiceUnavailable, `{"message":"service unavailable"}`, "service unavailable", false},
+		{http.StatusTooManyRequests, `{"error":"slow down"}`, "slow down", true},
+		{http.StatusNotFound, `<html>not found</html>`, "", false},
+	} {
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tc.status)
+			w.Write([]byte(tc.body))
+		})
+		res, err := c.PortScanner(context.Background(), "192.0.2.1")
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || res != nil {
+			t.Errorf("%d: got %v, %v; want a *StatusError and no result", tc.status, res, err)
+			continue
+		}
+		if statusErr.StatusCode != tc.status || statusErr.Message != tc.msg ||
+			statusErr.Endpoint != "portscanner" || statusErr.QuotaExceeded() != tc.quota {
+			t.Errorf("%d: got %+v", tc.status, statusErr)
+		}
+	}
+}
+
+func TestQuotaExceeded(t *testing.T) {
+	for _, tc := range []struct {
+		msg  string
+		want bool
+	}{
+		{"You have reached your daily API limit.", true},
+		{"Monthly limit reached", true},
+		{"Rate limit exceeded", true},
+		{"Quota exhausted", true},
+		{"You are out of credits", true},
+		{"Too many requests", true},
+		{"Character limit exceeded", false},
+		{"limit must be 1-100", false},
+		{"Invalid host", false},
+		{"", false},
+	} {
+		if got := (&APIError{Message: tc.msg}).QuotaExceeded(); got != tc.want {
+			t.Errorf("QuotaExceeded(%q) = %v, want %v", tc.msg, got, tc.want)
+		}
+	}
+}
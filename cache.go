@@ -0,0 +1,88 @@
+package c99
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"c99/internal/atomicfile"
+)
+
+// Cache stores raw API responses keyed by request. Only successful
+// responses are cached.
+type Cache interface {
+	// Get returns the cached response for key and when it was stored.
+	Get(key string) (data []byte, stored time.Time, ok bool)
+	Set(key string, data []byte) error
+}
+
+// FileCache is a Cache keeping one file per response in Dir. Entries older
+// than TTL are ignored; a zero TTL keeps them forever.
+type FileCache struct {
+	Dir string
+	TTL time.Duration
+}
+
+func (fc *FileCache) path(key string) string {
+	return filepath.Join(fc.Dir, key+".json")
+}
+
+func (fc *FileCache) Get(key string) ([]byte, time.Time, bool) {
+	st, err := os.Stat(fc.path(key))
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	if fc.TTL > 0 && time.Since(st.ModTime()) > fc.TTL {
+		return nil, time.Time{}, false
+	}
+	data, err := os.ReadFile(fc.path(key))
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	return data, st.ModTime(), true
+}
+
+func (fc *FileCache) Set(key string, data []byte) error {
+	if err := os.MkdirAll(fc.Dir, 0o700); err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(fc.path(key), data)
+}
+
+// uncachedEndpoints return a different answer on every call, or act on
+// server-side state, so their responses are never cached.
+var uncachedEndpoints = map[string]bool{
+	"randomnumber":        true,
+	"passwordgenerator":   true,
+	"licensekeygenerator": true,
+	"randomperson":        true,
+	"randomstringpicker":  true,
+	"eitheror":            true,
+	"iplogger":            true,
+	"urlshortener":        true,
+	"linkbackup":          true,
+}
+
+// cacheKey identifies a request by endpoint and parameters. The API key is
+// left out so that rotating keys does not invalidate the cache.
+func cacheKey(endpoint string, params map[string]string) string {
+	names := make([]string, 0, len(params))
+	for k := range params {
+		if k != "key" {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(endpoint)
+	for _, k := range names {
+		b.WriteString("\x00" + k + "=" + params[k])
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return endpoint + "-" + hex.EncodeToString(sum[:12])
+}
@@ -0,0 +1,40 @@
+package c99
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// brokenCache stores nothing and fails every Set.
+type brokenCache struct{ sets int }
+
+func (bc *brokenCache) Get(key string) ([]byte, time.Time, bool) { return nil, time.Time{}, false }
+
+func (bc *brokenCache) Set(key string, data []byte) error {
+	bc.sets++
+	return errors.New("disk full")
+}
+
+func TestCacheSetFailure(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success":true,"open_ports":[80]}`))
+	})
+	cache := &brokenCache{}
+	c.Cache = cache
+	for range 2 {
+		if _, err := c.PortScanner(context.Background(), "192.0.2.1"); err != nil {
+			t.Fatalf("a failing cache failed the call: %v", err)
+		}
+	}
+	var errs []error
+	c.OnCacheError = func(err error) { errs = append(errs, err) }
+	if _, err := c.PortScanner(context.Background(), "192.0.2.1"); err != nil {
+		t.Fatalf("a failing cache failed the call: %v", err)
+	}
+	if cache.sets != 3 || len(errs) != 1 || errs[0].Error() != "disk full" {
+		t.Errorf("after %d sets, OnCacheError got %v; want disk full once", cache.sets, errs)
+	}
+}
@@ -0,0 +1,457 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"c99"
+)
+
+// batchOptions holds the flags of the batch command.
+type batchOptions struct {
+	Input     string
+	Parallel  int
+	KeepOrder bool
+	CSV       bool
+	Header    bool
+	// Out is the results file; empty means stdout.
+	Out string
+	// Checkpoint is the checkpoint file of a resumable job; Resume is set
+	// too when resuming one.
+	Checkpoint string
+	Resume     string
+}
+
+// batchRow is one unit of work read from the batch input.
+type batchRow struct {
+	Index int
+	// Input is the raw line, or the fields of a CSV record.
+	Input interface{}
+	Args  *methodArgs
+}
+
+// batchRecord is the NDJSON line written for each batch row.
+type batchRecord struct {
+	Index     int         `json:"index"`
+	Input     interface{} `json:"input"`
+	Result    interface{} `json:"result,omitempty"`
+	Request   interface{} `json:"request,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	ErrorCode string      `json:"error_code,omitempty"`
+	LatencyMS int64       `json:"latency_ms"`
+	Meta      *c99.Meta   `json:"meta,omitempty"`
+
+	// exit is the exit code for a failed call, named by ErrorCode. curl is
+	// the curl command for the request of a dry run, which Request
+	// describes.
+	exit int
+	curl string
+}
+
+func printBatchUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: c99_api batch <method> [--input <file>] [--parallel <n>] [--keep-order]")
+	fmt.Fprintln(w, "                     [--csv [--header]] [--out <file> [--checkpoint <file>]]")
+	fmt.Fprintln(w, "                     [--<param> <value>...]")
+	fmt.Fprintln(w, "       c99_api batch --resume <checkpoint> [--parallel <n>] [--keep-order]")
+	fmt.Fprintln(w, "Runs the method once per input line (or CSV record) read from the file or stdin")
+	fmt.Fprintln(w, "and writes one NDJSON line per call with its input, result, error and latency.")
+	fmt.Fprintln(w, "Each line supplies the method's leading parameters; fixed parameters for every")
+	fmt.Fprintln(w, "call are given by name. With --header, CSV columns are matched to parameters by name.")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  -i, --input <file>   read inputs from file instead of stdin ('-' for stdin)")
+	fmt.Fprintln(w, "  -p, --parallel <n>   number of concurrent calls (default 4)")
+	fmt.Fprintln(w, "  --keep-order         write results in input order")
+	fmt.Fprintln(w, "  --csv                parse the input as CSV, one call per record")
+	fmt.Fprintln(w, "  --header             treat the first CSV record as parameter names")
+	fmt.Fprintln(w, "  --out <file>         append results to file instead of writing to stdout")
+	fmt.Fprintln(w, "  --checkpoint <file>  make the job resumable; needs --input and --out")
+	fmt.Fprintln(w, "  --resume <file>      continue a checkpointed job, retrying failed calls")
+}
+
+// parseBatchFlags separates the batch command's own flags from the
+// method's fixed arguments.
+func parseBatchFlags(args []string) (*batchOptions, []string, error) {
+	opts := &batchOptions{Input: "-", Parallel: 4}
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		needValue := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("flag %s needs a value", name)
+			}
+			i++
+			return args[i], nil
+		}
+
+		var err error
+		switch name {
+		case "-i", "--input":
+			opts.Input, err = needValue()
+		case "-p", "--parallel":
+			var v string
+			if v, err = needValue(); err == nil {
+				opts.Parallel, err = strconv.Atoi(v)
+				if err == nil && opts.Parallel < 1 {
+					err = fmt.Errorf("--parallel must be at least 1")
+				}
+			}
+		case "--out":
+			opts.Out, err = needValue()
+		case "--checkpoint":
+			opts.Checkpoint, err = needValue()
+		case "--resume":
+			opts.Resume, err = needValue()
+			opts.Checkpoint = opts.Resume
+		case "--keep-order":
+			opts.KeepOrder = true
+		case "--csv":
+			opts.CSV = true
+		case "--header":
+			opts.Header = true
+		default:
+			rest = append(rest, args[i])
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if opts.Header && !opts.CSV {
+		return nil, nil, fmt.Errorf("--header requires --csv")
+	}
+	return opts, rest, nil
+}
+
+// readBatchRows streams rows from r to rows and closes it. Blank lines
+// and lines starting with # are skipped in line mode.
+func readBatchRows(r io.Reader, info *c99.MethodInfo, bopts *batchOptions, fixed map[string]string, rows chan<- batchRow) error {
+	defer close(rows)
+
+	newArgs := func() *methodArgs {
+		args := &methodArgs{Named: make(map[string]string, len(fixed))}
+		for k, v := range fixed {
+			args.Named[k] = v
+		}
+		return args
+	}
+
+	if !bopts.CSV {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		index := 0
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			args := newArgs()
+			args.Positional = []string{line}
+			rows <- batchRow{Index: index, Input: line, Args: args}
+			index++
+		}
+		return scanner.Err()
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	var columns []string
+	for index := 0; ; {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if bopts.Header && columns == nil {
+			for _, col := range record {
+				idx := findParam(info, col)
+				if idx < 0 {
+					return fmt.Errorf("CSV column '%s' is not a parameter of '%s'", col, info.Name)
+				}
+				columns = append(columns, info.Params[idx].Name)
+			}
+			continue
+		}
+
+		args := newArgs()
+		if columns != nil {
+			for i, field := range record {
+				if i < len(columns) {
+					args.Named[columns[i]] = field
+				}
+			}
+		} else {
+			args.Positional = record
+		}
+		rows <- batchRow{Index: index, Input: record, Args: args}
+		index++
+	}
+}
+
+// runBatch implements the batch command and returns the process exit code.
+func runBatch(opts *cliOptions, args []string) int {
+	if len(args) < 1 {
+		printBatchUsage(os.Stderr)
+		return exitUsage
+	}
+	if args[0] == "-h" || args[0] == "--help" {
+		printBatchUsage(os.Stdout)
+		return exitOK
+	}
+
+	bopts, rest, err := parseBatchFlags(args)
+	if err != nil {
+		return opts.report(usageError(err, nil))
+	}
+
+	var job *batchJob
+	if bopts.Resume != "" {
+		if len(rest) > 0 {
+			return opts.report(usageError(errors.New("--resume takes the method and parameters from the checkpoint file"), nil))
+		}
+		if job, err = loadBatchJob(bopts.Resume); err == nil {
+			err = job.apply(opts, bopts)
+		}
+	} else {
+		job, err = newBatchJob(opts, bopts, rest)
+	}
+	if err != nil {
+		return opts.report(usageError(err, nil))
+	}
+	info := c99.LookupMethod(job.Method)
+	if info == nil {
+		return opts.report(unknownMethodError(job.Method))
+	}
+	if opts.APIKey == "" && !opts.DryRun {
+		return opts.report(errNoAPIKey)
+	}
+
+	// done holds the rows already completed by an earlier run of a
+	// checkpointed job. A dry run leaves the checkpoint and results files
+	// alone.
+	done := make(map[int]bool)
+	if bopts.Checkpoint != "" {
+		if err := job.checkInput(); err != nil {
+			return opts.report(usageError(err, nil))
+		}
+		switch {
+		case bopts.Resume != "" && opts.DryRun:
+			done, _, err = readBatchOutput(job.Out)
+		case bopts.Resume != "":
+			done, err = recoverBatchOutput(job.Out)
+		case !opts.DryRun:
+			err = saveBatchJob(bopts.Checkpoint, job)
+		}
+		if err != nil {
+			return opts.report(err)
+		}
+	}
+
+	in := os.Stdin
+	if bopts.Input != "-" {
+		f, err := os.Open(bopts.Input)
+		if err != nil {
+			return opts.report(err)
+		}
+		defer f.Close()
+		in = f
+	}
+	out := os.Stdout
+	if bopts.Out != "" && !opts.DryRun {
+		f, err := os.OpenFile(bopts.Out, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return opts.report(err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	c, err := opts.newClient()
+	if err != nil {
+		return opts.report(usageError(err, nil))
+	}
+	// Share one connection pool across workers rather than dialing per call.
+	client := http.Client{Transport: http.DefaultTransport}
+	if c.HTTPClient != nil {
+		client = *c.HTTPClient
+	}
+	if t, ok := client.Transport.(*http.Transport); ok {
+		t = t.Clone()
+		t.MaxIdleConnsPerHost = bopts.Parallel
+		client.Transport = t
+	}
+	c.HTTPClient = &client
+
+	rows := make(chan batchRow)
+	todo := make(chan batchRow)
+	records := make(chan batchRecord)
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- readBatchRows(in, info, bopts, job.Fixed, rows)
+	}()
+	go func() {
+		defer close(todo)
+		for row := range rows {
+			if !done[row.Index] {
+				todo <- row
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < bopts.Parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for row := range todo {
+				records <- runBatchRow(c, info, opts, row)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(records)
+	}()
+
+	start := time.Now()
+	total, failed := 0, 0
+	// failExit is the exit code shared by all failed calls, or
+	// exitBatchPartial once they differ.
+	failExit := exitOK
+	var writeErr error
+	write := func(rec batchRecord) {
+		total++
+		if rec.Error != "" {
+			failed++
+			if failExit == exitOK {
+				failExit = rec.exit
+			} else if failExit != rec.exit {
+				failExit = exitBatchPartial
+			}
+		}
+		// One write per record keeps each line whole in an O_APPEND file.
+		line, err := json.Marshal(rec)
+		if opts.AsCurl {
+			// Written as a shell script, with failed rows commented out.
+			line = []byte(rec.curl)
+			if rec.Error != "" {
+				line = []byte(fmt.Sprintf("# %d: %s", rec.Index, rec.Error))
+			}
+		}
+		if err == nil {
+			_, err = out.Write(append(line, '\n'))
+		}
+		if err != nil && writeErr == nil {
+			writeErr = err
+		}
+	}
+
+	pending := make(map[int]batchRecord)
+	next := 0
+	for rec := range records {
+		if !bopts.KeepOrder {
+			write(rec)
+			continue
+		}
+		pending[rec.Index] = rec
+		for {
+			for done[next] {
+				next++
+			}
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			write(r)
+			next++
+		}
+	}
+
+	summary := fmt.Sprintf("batch: %d calls, %d succeeded, %d failed", total, total-failed, failed)
+	if opts.DryRun {
+		calls := total - failed
+		summary = fmt.Sprintf("batch dry run: %d calls, about %d credits, %d invalid rows", calls, calls*creditsPerCall, failed)
+	}
+	if len(done) > 0 {
+		summary += fmt.Sprintf(", %d already done", len(done))
+	}
+	if opts.ErrorFormat != "json" {
+		fmt.Fprintf(os.Stderr, "%s in %s\n", summary, time.Since(start).Round(time.Millisecond))
+	}
+	if err := <-readErr; err != nil {
+		return opts.report(fmt.Errorf("reading input: %v", err))
+	}
+	if writeErr != nil {
+		return opts.report(fmt.Errorf("writing results: %v", writeErr))
+	}
+	if failed > 0 {
+		// A batch where every call failed the same way exits as a single
+		// call would; any success makes it a partial failure.
+		code := exitBatchPartial
+		if failed == total && len(done) == 0 {
+			code = failExit
+		}
+		if opts.ErrorFormat == "json" {
+			return opts.report(&cliError{Exit: code, Err: errors.New(summary), Method: info.Name})
+		}
+		if bopts.Checkpoint != "" && !opts.DryRun {
+			fmt.Fprintf(os.Stderr, "Retry the failed calls with: c99_api batch --resume %s\n", bopts.Checkpoint)
+		}
+		return code
+	}
+	return exitOK
+}
+
+// runBatchRow performs a single batch call.
+func runBatchRow(c *c99.C99, info *c99.MethodInfo, opts *cliOptions, row batchRow) batchRecord {
+	rec := batchRecord{Index: row.Index, Input: row.Input}
+	fail := func(exit int, err error) batchRecord {
+		rec.Error, rec.ErrorCode, rec.exit = err.Error(), errorCodes[exit], exit
+		return rec
+	}
+	callArgs, err := info.ResolveArgs(row.Args.Positional, row.Args.Named, opts.methodDefaults(info))
+	if err != nil {
+		return fail(exitUsage, err)
+	}
+	if opts.DryRun {
+		req, err := dryRun(c, info, callArgs)
+		if err != nil {
+			return fail(exitFailure, err)
+		}
+		rec.Request, rec.curl = describeRequest(req), curlCommand(req)
+		return rec
+	}
+
+	start := time.Now()
+	var result map[string]interface{}
+	if opts.WithMeta {
+		var resp *c99.Response
+		resp, err = c.CallMethod(context.Background(), info, callArgs)
+		result, rec.Meta = resp.Result, resp.Meta
+	} else {
+		result, err = info.Call(context.Background(), c, callArgs)
+	}
+	rec.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		return fail(callExit(err), err)
+	}
+	rec.Result = opts.postProcess(result)
+	return rec
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parseRecords parses batch output written to stdout, in output order.
+func parseRecords(t *testing.T, out string) []batchRecord {
+	t.Helper()
+	var recs []batchRecord
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		var rec batchRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("bad results line %s: %v", line, err)
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+func indexes(recs []batchRecord) []int {
+	out := make([]int, len(recs))
+	for i, rec := range recs {
+		out[i] = rec.Index
+	}
+	return out
+}
+
+// writeInput writes a batch input file and returns its path.
+func writeInput(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "in.txt")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBatchRecords(t *testing.T) {
+	in := writeInput(t, "192.0.2.1\n\n# comment\nbad\n 192.0.2.3 \n")
+	_, opts := newFakeAPI(t, "bad")
+	var code int
+	stdout, stderr := capture(t, func() { code = runBatch(opts, []string{"PortScanner", "-i", in, "--keep-order"}) })
+	if code != exitBatchPartial {
+		t.Errorf("exit %d, want %d", code, exitBatchPartial)
+	}
+	recs := parseRecords(t, stdout)
+	if got := indexes(recs); !reflect.DeepEqual(got, []int{0, 1, 2}) {
+		t.Fatalf("rows %v, want 0, 1 and 2", got)
+	}
+	for i, input := range []string{"192.0.2.1", "bad", "192.0.2.3"} {
+		if recs[i].Input != input {
+			t.Errorf("row %d input = %v, want %q", i, recs[i].Input, input)
+		}
+	}
+	if res, _ := recs[0].Result.(map[string]interface{}); res["success"] != true || recs[0].Error != "" {
+		t.Errorf("row 0 = %+v, want a result", recs[0])
+	}
+	if recs[1].Result != nil || !strings.Contains(recs[1].Error, "Host unreachable") || recs[1].ErrorCode != "api_error" {
+		t.Errorf("row 1 = %+v, want an api_error", recs[1])
+	}
+	// latency_ms is written even when it rounds to zero.
+	if !strings.Contains(stdout, `"latency_ms":`) {
+		t.Errorf("no latency_ms in %s", stdout)
+	}
+	if !regexp.MustCompile(`^batch: 3 calls, 2 succeeded, 1 failed in \d+ms\n$`).MatchString(stderr) {
+		t.Errorf("stderr = %q, want the summary line", stderr)
+	}
+}
+
+func TestBatchOrder(t *testing.T) {
+	in := writeInput(t, "slow\n192.0.2.2\n192.0.2.3\n")
+	api, opts := newFakeAPI(t)
+	api.delay["slow"] = 200 * time.Millisecond
+
+	// Without --keep-order results are written as they finish, so the
+	// slow first row comes last.
+	stdout, _ := capture(t, func() { runBatch(opts, []string{"PortScanner", "-i", in, "-p", "3"}) })
+	got := indexes(parseRecords(t, stdout))
+	if len(got) != 3 || got[2] != 0 {
+		t.Errorf("unordered rows %v, want row 0 last", got)
+	}
+	sort.Ints(got)
+	if !reflect.DeepEqual(got, []int{0, 1, 2}) {
+		t.Errorf("unordered rows %v, want each row once", got)
+	}
+
+	stdout, _ = capture(t, func() { runBatch(opts, []string{"PortScanner", "-i", in, "-p", "3", "--keep-order"}) })
+	if got := indexes(parseRecords(t, stdout)); !reflect.DeepEqual(got, []int{0, 1, 2}) {
+		t.Errorf("--keep-order rows %v, want input order", got)
+	}
+}
+
+func TestBatchExitCodes(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		code  int
+	}{
+		{"192.0.2.1\n192.0.2.2\n", exitOK},
+		{"192.0.2.1\nbad\n", exitBatchPartial},
+		// Every call failing the same way exits as a single call would.
+		{"bad\nbad\n", exitAPIError},
+		{"bad\nbusy\n", exitBatchPartial},
+	} {
+		api, opts := newFakeAPI(t, "bad")
+		api.status["busy"] = http.StatusServiceUnavailable
+		var code int
+		capture(t, func() { code = runBatch(opts, []string{"PortScanner", "-i", writeInput(t, tc.input)}) })
+		if code != tc.code {
+			t.Errorf("%q: exit %d, want %d", tc.input, code, tc.code)
+		}
+	}
+}
+
+func TestBatchCSV(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		input string
+		flags []string
+		want  []interface{}
+	}{
+		{"positional", "192.0.2.1,443\n192.0.2.2, 22\n", nil, []interface{}{"192.0.2.2", "22"}},
+		{"header", "port,host\n443,192.0.2.1\n22,192.0.2.2\n", []string{"--header"}, []interface{}{"22", "192.0.2.2"}},
+	} {
+		api, opts := newFakeAPI(t)
+		args := append([]string{"CheckPort", "-i", writeInput(t, tc.input), "--csv", "--keep-order"}, tc.flags...)
+		var code int
+		stdout, stderr := capture(t, func() { code = runBatch(opts, args) })
+		if code != exitOK {
+			t.Fatalf("%s: exit %d, stderr %s", tc.name, code, stderr)
+		}
+		hosts := api.takeHosts()
+		sort.Strings(hosts)
+		if !reflect.DeepEqual(hosts, []string{"192.0.2.1", "192.0.2.2"}) {
+			t.Errorf("%s: called %q", tc.name, hosts)
+		}
+		// The input of a CSV row is its fields.
+		if recs := parseRecords(t, stdout); len(recs) != 2 || !reflect.DeepEqual(recs[1].Input, tc.want) {
+			t.Errorf("%s: records %+v, want row 1 input %q", tc.name, recs, tc.want)
+		}
+	}
+}
+
+func TestBatchStdin(t *testing.T) {
+	saved := os.Stdin
+	defer func() { os.Stdin = saved }()
+	f, err := os.Open(writeInput(t, "192.0.2.1\n192.0.2.2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	os.Stdin = f
+
+	api, opts := newFakeAPI(t)
+	var code int
+	stdout, _ := capture(t, func() { code = runBatch(opts, []string{"PortScanner", "--keep-order"}) })
+	if recs := parseRecords(t, stdout); code != exitOK || len(recs) != 2 || recs[1].Input != "192.0.2.2" {
+		t.Errorf("exit %d, records %+v", code, recs)
+	}
+	if hosts := api.takeHosts(); len(hosts) != 2 {
+		t.Errorf("called %q, want both stdin rows", hosts)
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"c99"
+	"c99/internal/atomicfile"
+)
+
+// batchJob describes a batch run. For resumable jobs it is saved in the
+// checkpoint file. Progress itself is not duplicated there: the results
+// file is the only record of a completed call, so the two can never
+// disagree after a crash.
+type batchJob struct {
+	Version     int               `json:"version"`
+	Method      string            `json:"method"`
+	Input       string            `json:"input"`
+	InputSHA256 string            `json:"input_sha256,omitempty"`
+	CSV         bool              `json:"csv,omitempty"`
+	Header      bool              `json:"header,omitempty"`
+	Fixed       map[string]string `json:"fixed,omitempty"`
+	Out         string            `json:"out,omitempty"`
+	Select      string            `json:"select,omitempty"`
+	Where       []string          `json:"where,omitempty"`
+}
+
+const batchJobVersion = 1
+
+// newBatchJob builds the job for a fresh batch run from its command line.
+func newBatchJob(opts *cliOptions, bopts *batchOptions, args []string) (*batchJob, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("batch needs a method name")
+	}
+	info := c99.LookupMethod(args[0])
+	if info == nil {
+		return nil, unknownMethodError(args[0])
+	}
+	fixed, err := parseMethodArgs(info, args[1:])
+	if err != nil {
+		return nil, err
+	}
+	if len(fixed.Positional) > 0 {
+		return nil, fmt.Errorf("batch reads per-call values from the input; give fixed parameters by name")
+	}
+
+	job := &batchJob{
+		Version: batchJobVersion,
+		Method:  info.Name,
+		Input:   bopts.Input,
+		CSV:     bopts.CSV,
+		Header:  bopts.Header,
+		Fixed:   fixed.Named,
+		Out:     bopts.Out,
+	}
+	if opts.Select != nil {
+		job.Select = opts.Select.String()
+	}
+	for _, f := range opts.Where {
+		job.Where = append(job.Where, f.String())
+	}
+
+	if bopts.Checkpoint == "" {
+		return job, nil
+	}
+	if bopts.Input == "-" || bopts.Out == "" {
+		return nil, fmt.Errorf("--checkpoint needs --input and --out files")
+	}
+	if _, err := os.Stat(bopts.Checkpoint); err == nil {
+		return nil, fmt.Errorf("checkpoint %s already exists; continue it with --resume", bopts.Checkpoint)
+	}
+	if st, err := os.Stat(bopts.Out); err == nil && st.Size() > 0 {
+		return nil, fmt.Errorf("results file %s already exists", bopts.Out)
+	}
+	if job.Input, err = filepath.Abs(bopts.Input); err != nil {
+		return nil, err
+	}
+	if job.Out, err = filepath.Abs(bopts.Out); err != nil {
+		return nil, err
+	}
+	if job.InputSHA256, err = hashFile(job.Input); err != nil {
+		return nil, err
+	}
+	bopts.Input, bopts.Out = job.Input, job.Out
+	return job, nil
+}
+
+// apply restores a resumed job's settings onto the command options.
+func (job *batchJob) apply(opts *cliOptions, bopts *batchOptions) error {
+	bopts.Input = job.Input
+	bopts.Out = job.Out
+	bopts.CSV = job.CSV
+	bopts.Header = job.Header
+	if job.Select != "" {
+		p, err := c99.ParsePath(job.Select)
+		if err != nil {
+			return err
+		}
+		opts.Select = p
+	}
+	opts.Where = nil
+	for _, expr := range job.Where {
+		f, err := c99.ParseFilter(expr)
+		if err != nil {
+			return err
+		}
+		opts.Where = append(opts.Where, f)
+	}
+	return nil
+}
+
+// checkInput verifies that the input file is unchanged since the job was
+// created, since completed rows are identified by their position in it.
+func (job *batchJob) checkInput() error {
+	sum, err := hashFile(job.Input)
+	if err != nil {
+		return err
+	}
+	if sum != job.InputSHA256 {
+		return fmt.Errorf("input %s has changed since the job was started", job.Input)
+	}
+	return nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func loadBatchJob(path string) (*batchJob, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var job batchJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("reading checkpoint %s: %v", path, err)
+	}
+	if job.Version != batchJobVersion {
+		return nil, fmt.Errorf("checkpoint %s has unsupported version %d", path, job.Version)
+	}
+	return &job, nil
+}
+
+func saveBatchJob(path string, job *batchJob) error {
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, append(data, '\n'))
+}
+
+// recoverBatchOutput prepares a results file for resuming. It keeps the
+// successful records, drops failed ones so they are retried, and discards
+// a partial last line left by an interrupted write. The file is replaced
+// atomically, and the indexes of the kept records are returned.
+func recoverBatchOutput(path string) (map[int]bool, error) {
+	done, kept, err := readBatchOutput(path)
+	if err != nil || kept == nil {
+		return done, err
+	}
+	return done, atomicfile.WriteFile(path, kept)
+}
+
+// readBatchOutput returns the indexes of the successful records in a
+// results file along with the lines holding them. The lines are nil if the
+// file does not exist.
+func readBatchOutput(path string) (map[int]bool, []byte, error) {
+	done := make(map[int]bool)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return done, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var kept bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var rec batchRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			continue
+		}
+		if rec.Error != "" || done[rec.Index] {
+			continue
+		}
+		done[rec.Index] = true
+		kept.Write(line)
+		kept.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return done, append([]byte{}, kept.Bytes()...), nil
+}
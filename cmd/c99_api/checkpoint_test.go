@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAPI answers PortScanner calls, failing those for the hosts in fail.
+// Hosts in status are answered with that HTTP status and a JSON body, and
+// hosts in delay are answered after that long.
+type fakeAPI struct {
+	mu     sync.Mutex
+	fail   map[string]bool
+	status map[string]int
+	delay  map[string]time.Duration
+	hosts  []string
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	host := r.URL.Query().Get("host")
+	f.mu.Lock()
+	f.hosts = append(f.hosts, host)
+	fail, code, delay := f.fail[host], f.status[host], f.delay[host]
+	f.mu.Unlock()
+	time.Sleep(delay)
+	if code != 0 {
+		w.WriteHeader(code)
+		w.Write([]byte(`{"message":"` + http.StatusText(code) + `"}`))
+		return
+	}
+	if fail {
+		w.Write([]byte(`{"success":false,"error":"Host unreachable"}`))
+		return
+	}
+	w.Write([]byte(`{"success":true,"open_ports":[80]}`))
+}
+
+// takeHosts returns the hosts asked for since the last call.
+func (f *fakeAPI) takeHosts() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	hosts := f.hosts
+	f.hosts = nil
+	return hosts
+}
+
+func newFakeAPI(t *testing.T, fail ...string) (*fakeAPI, *cliOptions) {
+	api := &fakeAPI{fail: make(map[string]bool), status: make(map[string]int), delay: make(map[string]time.Duration)}
+	for _, host := range fail {
+		api.fail[host] = true
+	}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	return api, &cliOptions{APIKey: "test-key", profile: &Profile{BaseURL: srv.URL}}
+}
+
+// readRecords reads a results file, keyed by row index.
+func readRecords(t *testing.T, path string) map[int]batchRecord {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recs := make(map[int]batchRecord)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var rec batchRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("bad results line %s: %v", scanner.Bytes(), err)
+		}
+		if _, dup := recs[rec.Index]; dup {
+			t.Errorf("row %d written twice", rec.Index)
+		}
+		recs[rec.Index] = rec
+	}
+	return recs
+}
+
+func TestBatchCheckpointResume(t *testing.T) {
+	dir := t.TempDir()
+	in, out, ckpt := filepath.Join(dir, "in.txt"), filepath.Join(dir, "out.ndjson"), filepath.Join(dir, "job.json")
+	if err := os.WriteFile(in, []byte("192.0.2.1\n# comment\nbad\n192.0.2.3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	api, opts := newFakeAPI(t, "bad")
+	code := runBatch(opts, []string{"PortScanner", "--input", in, "--out", out, "--checkpoint", ckpt})
+	if code != exitBatchPartial {
+		t.Fatalf("first run exited %d, want %d", code, exitBatchPartial)
+	}
+	job, err := loadBatchJob(ckpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Method != "PortScanner" || job.Input != in || job.Out != out || job.InputSHA256 == "" {
+		t.Errorf("checkpoint = %+v", job)
+	}
+	recs := readRecords(t, out)
+	if len(recs) != 3 || recs[1].Error == "" || recs[0].Error != "" || recs[2].Error != "" {
+		t.Fatalf("first run wrote %+v", recs)
+	}
+	api.takeHosts()
+
+	// A rerun without --resume refuses to clobber the job.
+	if code := runBatch(opts, []string{"PortScanner", "--input", in, "--out", out, "--checkpoint", ckpt}); code != exitUsage {
+		t.Errorf("restarting a checkpointed job exited %d, want %d", code, exitUsage)
+	}
+
+	// Resuming retries only the failed row, and drops its failure.
+	api.fail["bad"] = false
+	opts = &cliOptions{APIKey: opts.APIKey, profile: opts.profile}
+	if code := runBatch(opts, []string{"--resume", ckpt}); code != exitOK {
+		t.Fatalf("resume exited %d, want %d", code, exitOK)
+	}
+	if hosts := api.takeHosts(); !reflect.DeepEqual(hosts, []string{"bad"}) {
+		t.Errorf("resume called %q, want only the failed row", hosts)
+	}
+	recs = readRecords(t, out)
+	if len(recs) != 3 {
+		t.Fatalf("after resume the results hold %d rows, want 3", len(recs))
+	}
+	for i, rec := range recs {
+		if rec.Error != "" || rec.Result == nil {
+			t.Errorf("row %d after resume: %+v", i, rec)
+		}
+	}
+
+	// Nothing is left to do, so a second resume makes no calls.
+	if code := runBatch(opts, []string{"--resume", ckpt}); code != exitOK || len(api.takeHosts()) != 0 {
+		t.Errorf("second resume exited %d and made calls", code)
+	}
+}
+
+func TestBatchResumeInputChanged(t *testing.T) {
+	dir := t.TempDir()
+	in, out, ckpt := filepath.Join(dir, "in.txt"), filepath.Join(dir, "out.ndjson"), filepath.Join(dir, "job.json")
+	if err := os.WriteFile(in, []byte("192.0.2.1\n192.0.2.2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	api, opts := newFakeAPI(t, "192.0.2.2")
+	runBatch(opts, []string{"PortScanner", "-i", in, "--out", out, "--checkpoint", ckpt})
+	api.takeHosts()
+	before, _ := os.ReadFile(out)
+
+	// Rows are identified by position, so an edited input cannot resume.
+	if err := os.WriteFile(in, []byte("192.0.2.9\n192.0.2.1\n192.0.2.2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code := runBatch(opts, []string{"--resume", ckpt}); code != exitUsage {
+		t.Errorf("resume with a changed input exited %d, want %d", code, exitUsage)
+	}
+	if hosts := api.takeHosts(); len(hosts) != 0 {
+		t.Errorf("resume with a changed input called %q", hosts)
+	}
+	if after, _ := os.ReadFile(out); !bytes.Equal(before, after) {
+		t.Errorf("resume with a changed input rewrote the results:\n%s", after)
+	}
+}
+
+func TestReadBatchOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.ndjson")
+	lines := []string{
+		`{"index":0,"input":"a","result":{"success":true},"latency_ms":1}`,
+		`{"index":1,"input":"b","error":"Host unreachable","error_code":"api_error","latency_ms":1}`,
+		`not json`,
+		`{"index":2,"input":"c","result":{"success":true},"latency_ms":1}`,
+		// A retried row can appear twice after a crash; the first wins.
+		`{"index":2,"input":"c","result":{"success":true},"latency_ms":2}`,
+		`{"index":1,"input":"b","result":{"success":true},"latency_ms":1}`,
+		// An interrupted write leaves a partial last line.
+		`{"index":3,"input":"d","res`,
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	done, kept, err := readBatchOutput(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[int]bool{0: true, 1: true, 2: true}; !reflect.DeepEqual(done, want) {
+		t.Errorf("done = %v, want %v", done, want)
+	}
+	if want := lines[0] + "\n" + lines[3] + "\n" + lines[5] + "\n"; string(kept) != want {
+		t.Errorf("kept:\n%s\nwant:\n%s", kept, want)
+	}
+
+	done, kept, err = readBatchOutput(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || len(done) != 0 || kept != nil {
+		t.Errorf("missing file = %v, %q, %v", done, kept, err)
+	}
+}
+
+func TestRecoverBatchOutput(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.ndjson")
+	ok := `{"index":0,"input":"a","result":{"success":true},"latency_ms":1}`
+	if err := os.WriteFile(path, []byte(ok+"\n"+`{"index":1,"input":"b","error":"x","latency_ms":1}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer old.Close()
+	oldInfo, _ := old.Stat()
+
+	done, err := recoverBatchOutput(path)
+	if err != nil || !reflect.DeepEqual(done, map[int]bool{0: true}) {
+		t.Fatalf("recoverBatchOutput = %v, %v", done, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != ok+"\n" {
+		t.Errorf("results after recovery:\n%s", data)
+	}
+	// The file was replaced by a rename, not rewritten in place, so a
+	// crash mid-write cannot lose the completed rows.
+	newInfo, _ := os.Stat(path)
+	if os.SameFile(oldInfo, newInfo) {
+		t.Error("results file was rewritten in place")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("recovery left files behind: %v", entries)
+	}
+
+	missing := filepath.Join(dir, "missing")
+	if done, err := recoverBatchOutput(missing); err != nil || len(done) != 0 {
+		t.Errorf("missing file = %v, %v", done, err)
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Error("recovery created a results file")
+	}
+}
+
+func TestLoadBatchJob(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "job.json")
+	job := &batchJob{Version: batchJobVersion, Method: "PortScanner", Input: "/in", Where: []string{".port > 1"}}
+	if err := saveBatchJob(path, job); err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadBatchJob(path)
+	if err != nil || !reflect.DeepEqual(got, job) {
+		t.Errorf("loadBatchJob = %+v, %v; want %+v", got, err, job)
+	}
+
+	for _, tc := range []struct{ data, err string }{
+		{`{"version":2}`, "unsupported version 2"},
+		{`{`, "reading checkpoint"},
+	} {
+		os.WriteFile(path, []byte(tc.data), 0o644)
+		if _, err := loadBatchJob(path); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("loadBatchJob(%s) error = %v, want %q", tc.data, err, tc.err)
+		}
+	}
+}
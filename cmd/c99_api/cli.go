@@ -0,0 +1,720 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"c99"
+)
+
+// apiKeyEnv names the environment variable consulted when no API key is
+// given on the command line.
+const apiKeyEnv = "C99_API_KEY"
+
+// methodArgs holds the arguments given to a method on the command line,
+// either positionally or as --name value flags.
+type methodArgs struct {
+	Positional []string
+	Named      map[string]string
+	Help       bool
+}
+
+// findParam returns the index of the parameter matching name, or -1.
+func findParam(info *c99.MethodInfo, name string) int {
+	key := c99.FoldName(name)
+	for i, param := range info.Params {
+		if c99.FoldName(param.Name) == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseMethodArgs splits a method's command line into positional values and
+// named values. Named values may be given as "--name value", "--name=value"
+// or "--param name=value"; "--" ends flag parsing.
+func parseMethodArgs(info *c99.MethodInfo, args []string) (*methodArgs, error) {
+	parsed := &methodArgs{Named: make(map[string]string)}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			parsed.Positional = append(parsed.Positional, args[i+1:]...)
+			break
+		}
+		if arg == "-h" || arg == "--help" {
+			parsed.Help = true
+			continue
+		}
+		if !strings.HasPrefix(arg, "--") {
+			parsed.Positional = append(parsed.Positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("flag --%s needs a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		if name == "param" {
+			pair := value
+			if name, value, hasValue = strings.Cut(pair, "="); !hasValue {
+				return nil, fmt.Errorf("--param expects key=value, got %q", pair)
+			}
+		}
+
+		idx := findParam(info, name)
+		if idx < 0 {
+			return nil, fmt.Errorf("unknown parameter '%s' for method '%s'", name, info.Name)
+		}
+		paramName := info.Params[idx].Name
+		if _, dup := parsed.Named[paramName]; dup {
+			return nil, fmt.Errorf("parameter '%s' given more than once", paramName)
+		}
+		parsed.Named[paramName] = value
+	}
+	return parsed, nil
+}
+
+// suggestMethods returns the method names closest to an unknown name,
+// measured by edit distance over the normalized Go and endpoint names.
+// Names containing the unknown one as a substring are also suggested.
+func suggestMethods(name string) []string {
+	key := c99.FoldName(name)
+	maxDist := len(key) / 3
+	if maxDist < 2 {
+		maxDist = 2
+	}
+
+	type candidate struct {
+		name string
+		dist int
+	}
+	var candidates []candidate
+	for _, info := range c99.Methods() {
+		dist := editDistance(key, c99.FoldName(info.Name))
+		if d := editDistance(key, info.Endpoint); d < dist {
+			dist = d
+		}
+		if len(key) >= 3 && strings.Contains(c99.FoldName(info.Name), key) {
+			dist = min(dist, maxDist)
+		}
+		if dist <= maxDist {
+			candidates = append(candidates, candidate{info.Name, dist})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].dist < candidates[j].dist
+	})
+
+	var names []string
+	for i := 0; i < len(candidates) && i < 3; i++ {
+		names = append(names, candidates[i].name)
+	}
+	return names
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// translatePythonArgs rewrites a c99.py style command line
+// ("--apikey KEY --method NAME --args A B" or "--list") into the native
+// form, so scripts written for the Python client run unchanged.
+func translatePythonArgs(args []string) []string {
+	var apiKey, method string
+	var methodArgs []string
+	list, python := false, false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--apikey":
+			if i+1 < len(args) {
+				i++
+				apiKey = args[i]
+			}
+		case "--method":
+			python = true
+			if i+1 < len(args) {
+				i++
+				method = args[i]
+			}
+		case "--args":
+			python = true
+			for i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+				i++
+				methodArgs = append(methodArgs, args[i])
+			}
+		case "--list":
+			python, list = true, true
+		default:
+			return args
+		}
+	}
+	if !python {
+		return args
+	}
+
+	var out []string
+	if apiKey != "" {
+		out = append(out, "--apikey", apiKey)
+	}
+	if list {
+		return append(out, "list")
+	}
+	out = append(out, method)
+	if len(methodArgs) > 0 {
+		out = append(out, "--")
+	}
+	return append(out, methodArgs...)
+}
+
+func printMethodUsage(w io.Writer, info *c99.MethodInfo) {
+	fmt.Fprintf(w, "Usage: %s ", info.Name)
+	for _, param := range info.Params {
+		if param.Required {
+			fmt.Fprintf(w, "<%s> ", param.Name)
+		} else {
+			fmt.Fprintf(w, "[%s] ", param.Name)
+		}
+	}
+	fmt.Fprintf(w, "\n   or: %s", info.Name)
+	for _, param := range info.Params {
+		if param.Required {
+			fmt.Fprintf(w, " --%s <value>", param.Name)
+		} else {
+			fmt.Fprintf(w, " [--%s <value>]", param.Name)
+		}
+	}
+	fmt.Fprintf(w, "\nDescription: %s\n", info.Description)
+	fmt.Fprintf(w, "Category: %s\n", info.Category)
+	if len(info.Params) > 0 {
+		fmt.Fprintln(w, "Parameters:")
+	}
+	for _, param := range info.Params {
+		req := "optional"
+		if param.Required {
+			req = "required"
+		} else if param.Default != "" {
+			req = fmt.Sprintf("optional, default %q", param.Default)
+		}
+		fmt.Fprintf(w, "  --%s (%s): %s\n", param.Name, param.Type, req)
+		if len(param.Enum) > 0 && len(param.Enum) <= 8 {
+			fmt.Fprintf(w, "      values: %s\n", strings.Join(param.Enum, ", "))
+		}
+	}
+	if len(info.Examples) > 0 {
+		fmt.Fprintln(w, "Examples:")
+	}
+	for _, ex := range info.Examples {
+		fmt.Fprintf(w, "  c99_api %s\n", ex)
+	}
+}
+
+// cliOptions holds the global command-line options.
+type cliOptions struct {
+	APIKey     string
+	Output     string
+	Template   string
+	Select     *c99.Path
+	Where      []*c99.Filter
+	ConfigPath string
+	Profile    string
+	// ErrorFormat is "text" or "json"; see report.
+	ErrorFormat string
+	// DryRun prints requests instead of sending them; AsCurl prints them
+	// as curl commands and implies DryRun.
+	DryRun bool
+	AsCurl bool
+	// Raw prints responses as the API sent them, without Normalize.
+	Raw bool
+	// WithMeta wraps each result in a Response carrying its metadata.
+	WithMeta bool
+
+	// profile is the config profile in effect, if any.
+	profile *Profile
+}
+
+// loadProfile reads the config file and selects the profile for this run.
+// Settings from the command line and environment take precedence.
+func (o *cliOptions) loadProfile() error {
+	cfg, err := loadConfig(o.ConfigPath)
+	if err != nil {
+		return err
+	}
+	if cfg == nil {
+		if o.Profile != "" {
+			return fmt.Errorf("profile '%s' given but no config file found", o.Profile)
+		}
+		return nil
+	}
+	if o.profile, err = cfg.profile(o.Profile); err != nil || o.profile == nil {
+		return err
+	}
+	if o.Output == "" && o.profile.Output != "" {
+		if err := setGlobalFlag(o, "output", o.profile.Output); err != nil {
+			return fmt.Errorf("profile: %v", err)
+		}
+	}
+	return nil
+}
+
+// profileAPIKey returns the API key configured in the selected profile.
+func (o *cliOptions) profileAPIKey() (string, error) {
+	if o.profile == nil {
+		return "", nil
+	}
+	return o.profile.apiKey()
+}
+
+// newClient returns a client for the API key and profile in effect.
+func (o *cliOptions) newClient() (*c99.C99, error) {
+	c := c99.NewC99(o.APIKey)
+	c.Raw = o.Raw
+	if o.profile != nil {
+		if err := o.profile.configure(c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// methodDefaults returns the selected profile's default parameters for a
+// method.
+func (o *cliOptions) methodDefaults(info *c99.MethodInfo) map[string]string {
+	return o.profile.methodDefaults(info)
+}
+
+// postProcess applies --where and then --select to a result.
+func (o *cliOptions) postProcess(result interface{}) interface{} {
+	result = c99.ApplyFilters(result, o.Where)
+	if o.Select != nil {
+		result = o.Select.Eval(result)
+	}
+	return result
+}
+
+// globalFlag describes a flag that may appear before the command or after
+// it. After a method name, a parameter of the same name takes precedence.
+type globalFlag struct {
+	Name  string
+	Short string
+	// Value names the flag's argument in usage output; boolean flags
+	// leave it empty.
+	Value string
+	Usage string
+	// Values lists the accepted values, offered by shell completion.
+	Values []string
+	Set    func(o *cliOptions, value string) error
+}
+
+var globalFlags = []globalFlag{
+	{
+		Name:  "profile",
+		Value: "name",
+		Usage: "config profile to use (default $" + profileEnv + " or default_profile)",
+		Set: func(o *cliOptions, v string) error {
+			o.Profile = v
+			return nil
+		},
+	},
+	{
+		Name:  "config",
+		Value: "file",
+		Usage: "config file (default $" + configEnv + " or ~/.config/c99/config.{toml,yaml})",
+		Set: func(o *cliOptions, v string) error {
+			o.ConfigPath = v
+			return nil
+		},
+	},
+	{
+		Name:  "apikey",
+		Value: "key",
+		Usage: "C99 API key (default $" + apiKeyEnv + ")",
+		Set: func(o *cliOptions, v string) error {
+			o.APIKey = v
+			return nil
+		},
+	},
+	{
+		Name:   "output",
+		Short:  "o",
+		Value:  "format",
+		Usage:  "output format: " + strings.Join(outputFormats, ", "),
+		Values: outputFormats,
+		Set: func(o *cliOptions, v string) error {
+			if !slices.Contains(outputFormats, v) {
+				return fmt.Errorf("unknown output format '%s' (want one of %s)", v, strings.Join(outputFormats, ", "))
+			}
+			o.Output = v
+			return nil
+		},
+	},
+	{
+		Name:  "template",
+		Value: "template",
+		Usage: "Go text/template for the result; implies --output template",
+		Set: func(o *cliOptions, v string) error {
+			o.Template = v
+			return nil
+		},
+	},
+	{
+		Name:   "error-format",
+		Value:  "format",
+		Usage:  "how errors are written to stderr: " + strings.Join(errorFormats, ", "),
+		Values: errorFormats,
+		Set: func(o *cliOptions, v string) error {
+			if !slices.Contains(errorFormats, v) {
+				return fmt.Errorf("unknown error format '%s' (want one of %s)", v, strings.Join(errorFormats, ", "))
+			}
+			o.ErrorFormat = v
+			return nil
+		},
+	},
+	{
+		Name:  "dry-run",
+		Usage: "print the request that would be sent, with the API key redacted, instead of sending it",
+		Set: func(o *cliOptions, v string) error {
+			o.DryRun = true
+			return nil
+		},
+	},
+	{
+		Name:  "as-curl",
+		Usage: "print the request as a curl command instead of sending it; implies --dry-run",
+		Set: func(o *cliOptions, v string) error {
+			o.DryRun, o.AsCurl = true, true
+			return nil
+		},
+	},
+	{
+		Name:  "raw",
+		Usage: "print responses as the API sent them instead of normalizing field types",
+		Set: func(o *cliOptions, v string) error {
+			o.Raw = true
+			return nil
+		},
+	},
+	{
+		Name:  "with-meta",
+		Usage: "wrap the result with how it was obtained: endpoint, status, latency, attempts, cache use",
+		Set: func(o *cliOptions, v string) error {
+			o.WithMeta = true
+			return nil
+		},
+	},
+	{
+		Name:  "select",
+		Value: "path",
+		Usage: "print only the part of the result at path, e.g. .subdomains[].ip",
+		Set: func(o *cliOptions, v string) (err error) {
+			o.Select, err = c99.ParsePath(v)
+			return err
+		},
+	},
+	{
+		Name:  "where",
+		Value: "filter",
+		Usage: "keep only list entries matching filter, e.g. '.ip != \"none\"' (repeatable)",
+		Set: func(o *cliOptions, v string) error {
+			f, err := c99.ParseFilter(v)
+			if err != nil {
+				return err
+			}
+			o.Where = append(o.Where, f)
+			return nil
+		},
+	},
+}
+
+// setGlobalFlag sets the named global flag as if given on the command line.
+func setGlobalFlag(o *cliOptions, name, value string) error {
+	for _, f := range globalFlags {
+		if f.Name == name {
+			return f.Set(o, value)
+		}
+	}
+	return fmt.Errorf("unknown flag --%s", name)
+}
+
+// lookupGlobalFlag returns the global flag named by arg, along with an
+// inline "=value" if one was given.
+func lookupGlobalFlag(arg string) (flag *globalFlag, value string, hasValue bool) {
+	var name string
+	switch {
+	case strings.HasPrefix(arg, "--"):
+		name, value, hasValue = strings.Cut(arg[2:], "=")
+	case strings.HasPrefix(arg, "-") && len(arg) > 1:
+		name, value, hasValue = strings.Cut(arg[1:], "=")
+	default:
+		return nil, "", false
+	}
+	long := strings.HasPrefix(arg, "--")
+	for i := range globalFlags {
+		f := &globalFlags[i]
+		if (long && f.Name == name) || (!long && f.Short != "" && f.Short == name) {
+			return f, value, hasValue
+		}
+	}
+	return nil, "", false
+}
+
+// parseGlobalFlags pulls the global flags out of args and returns the
+// remaining command line. Flags after a method name that match one of its
+// parameters are left for the method.
+func parseGlobalFlags(args []string) (*cliOptions, []string, error) {
+	opts := &cliOptions{}
+	var rest []string
+	var method *c99.MethodInfo
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		flag, value, hasValue := lookupGlobalFlag(arg)
+		if flag == nil || (method != nil && findParam(method, flag.Name) >= 0) {
+			rest = append(rest, arg)
+			if method == nil && !strings.HasPrefix(arg, "-") && !slices.Contains(builtinCommands, arg) {
+				method = c99.LookupMethod(arg)
+			}
+			continue
+		}
+		if flag.Value != "" && !hasValue {
+			if i+1 >= len(args) {
+				return opts, nil, fmt.Errorf("flag --%s needs a value", flag.Name)
+			}
+			i++
+			value = args[i]
+		}
+		if err := flag.Set(opts, value); err != nil {
+			return opts, nil, err
+		}
+	}
+
+	if opts.Template != "" && opts.Output == "" {
+		opts.Output = "template"
+	}
+	if opts.Output == "template" && opts.Template == "" {
+		return opts, nil, fmt.Errorf("--output template needs --template")
+	}
+	return opts, rest, nil
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: c99_api [flags] <method> [args...]")
+	fmt.Fprintln(w, "       c99_api [flags] <apikey> <method> [args...]")
+	fmt.Fprintln(w, "       c99_api <method> --help")
+	fmt.Fprintln(w, "       c99_api batch <method> [--input <file>] [--parallel <n>] ...")
+	fmt.Fprintln(w, "       c99_api watch [--interval <duration>] <method> [args...]")
+	fmt.Fprintln(w, "       c99_api list [--json]")
+	fmt.Fprintln(w, "       c99_api describe <method> [--json]")
+	fmt.Fprintln(w, "       c99_api search <term>... [--json]")
+	fmt.Fprintln(w, "       c99_api conformance [--replay <dir>] [--update] [method...]")
+	fmt.Fprintln(w, "       c99_api shell")
+	fmt.Fprintln(w, "       c99_api completion bash|zsh|fish")
+	fmt.Fprintln(w, "Flags:")
+	for _, f := range globalFlags {
+		name := "--" + f.Name
+		if f.Short != "" {
+			name = "-" + f.Short + ", " + name
+		}
+		if f.Value != "" {
+			name += " <" + f.Value + ">"
+		}
+		fmt.Fprintf(w, "  %-28s %s\n", name, f.Usage)
+	}
+	fmt.Fprintln(w, "Use 'list' as the method to see all available methods")
+	printExitCodes(w)
+}
+
+// splitAPIKey extracts a leading API key given in the legacy
+// "<apikey> <method>" form, falling back to the C99_API_KEY environment
+// variable and then to fallback, the key from the config profile.
+func splitAPIKey(args []string, fallback string) (string, []string) {
+	key := os.Getenv(apiKeyEnv)
+	if key == "" {
+		key = fallback
+	}
+	if len(args) >= 2 && !isCommand(args[0]) && !strings.HasPrefix(args[0], "-") {
+		// With a key configured elsewhere, only treat the first argument
+		// as a key when it is followed by a known method, so that a
+		// misspelled method name still gets suggestions.
+		if key == "" || isCommand(args[1]) {
+			return args[0], args[1:]
+		}
+	}
+	return key, args
+}
+
+// builtinCommands are the subcommands handled by the CLI itself.
+var builtinCommands = []string{"list", "describe", "search", "batch", "watch", "conformance", "shell", "completion"}
+
+// isCommand reports whether name is a method or built-in command rather
+// than an API key.
+func isCommand(name string) bool {
+	return slices.Contains(builtinCommands, name) || c99.LookupMethod(name) != nil
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == completeCommand {
+		for _, c := range completeArgs(os.Args[2:]) {
+			fmt.Println(c)
+		}
+		return
+	}
+	os.Exit(run(os.Args[1:]))
+}
+
+// run runs the command line args and returns the process exit code.
+func run(args []string) int {
+	opts, args, err := parseGlobalFlags(translatePythonArgs(args))
+	if err != nil {
+		return opts.report(usageError(err, nil))
+	}
+	if err := opts.loadProfile(); err != nil {
+		return opts.report(usageError(err, nil))
+	}
+	if opts.APIKey == "" {
+		profileKey, err := opts.profileAPIKey()
+		if err != nil {
+			return opts.report(usageError(err, nil))
+		}
+		opts.APIKey, args = splitAPIKey(args, profileKey)
+	}
+	if len(args) < 1 {
+		printUsage(os.Stderr)
+		return exitUsage
+	}
+	if args[0] == "-h" || args[0] == "--help" {
+		printUsage(os.Stdout)
+		return exitOK
+	}
+
+	method := args[0]
+	args = args[1:]
+
+	if method == "batch" {
+		return runBatch(opts, args)
+	}
+
+	if method == "completion" {
+		return runCompletion(args)
+	}
+
+	if method == "shell" {
+		return runShell(opts, args)
+	}
+
+	if method == "list" {
+		return runList(opts, args)
+	}
+
+	if method == "describe" {
+		return runDescribe(opts, args)
+	}
+
+	if method == "search" {
+		return runSearch(opts, args)
+	}
+
+	if method == "watch" {
+		return runWatch(opts, args)
+	}
+
+	if method == "conformance" {
+		return runConformance(opts, args)
+	}
+
+	methodInfo := c99.LookupMethod(method)
+	if methodInfo == nil {
+		return opts.report(unknownMethodError(method))
+	}
+
+	parsed, err := parseMethodArgs(methodInfo, args)
+	if err != nil {
+		return opts.report(methodUsageError(methodInfo, err))
+	}
+	if parsed.Help {
+		printMethodUsage(os.Stdout, methodInfo)
+		return exitOK
+	}
+
+	callArgs, err := methodInfo.ResolveArgs(parsed.Positional, parsed.Named, opts.methodDefaults(methodInfo))
+	if err != nil {
+		return opts.report(methodUsageError(methodInfo, err))
+	}
+
+	client, err := opts.newClient()
+	if err != nil {
+		return opts.report(usageError(err, nil))
+	}
+	if opts.DryRun {
+		req, err := dryRun(client, methodInfo, callArgs)
+		if err == nil {
+			err = opts.printDryRun(req)
+		}
+		if err != nil {
+			return opts.report(err)
+		}
+		return exitOK
+	}
+	if opts.APIKey == "" {
+		return opts.report(errNoAPIKey)
+	}
+
+	if opts.WithMeta {
+		// A failed call is written too, so that its metadata is not lost,
+		// before the error is reported.
+		resp, callErr := client.CallMethod(context.Background(), methodInfo, callArgs)
+		var result interface{} = resp.Result
+		if callErr == nil {
+			result = opts.postProcess(result)
+		}
+		meta, err := jsonValue(resp.Meta)
+		if err == nil {
+			err = writeResult(os.Stdout, opts, map[string]interface{}{"result": result, "meta": meta})
+		}
+		if err != nil {
+			return opts.report(fmt.Errorf("writing output: %v", err))
+		}
+		if callErr != nil {
+			return opts.report(callError(methodInfo.Name, callErr))
+		}
+		return exitOK
+	}
+
+	result, err := methodInfo.Call(context.Background(), client, callArgs)
+	if err != nil {
+		return opts.report(callError(methodInfo.Name, err))
+	}
+
+	if err := writeResult(os.Stdout, opts, opts.postProcess(result)); err != nil {
+		return opts.report(fmt.Errorf("writing output: %v", err))
+	}
+	return exitOK
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"c99"
+)
+
+// capture runs fn with os.Stdout and os.Stderr redirected, and returns
+// what it wrote to each.
+func capture(t *testing.T, fn func()) (stdout, stderr string) {
+	t.Helper()
+	dir := t.TempDir()
+	files := make([]*os.File, 2)
+	for i, name := range []string{"stdout", "stderr"} {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		files[i] = f
+	}
+	saved := []*os.File{os.Stdout, os.Stderr}
+	os.Stdout, os.Stderr = files[0], files[1]
+	defer func() { os.Stdout, os.Stderr = saved[0], saved[1] }()
+	fn()
+	out, _ := os.ReadFile(files[0].Name())
+	errOut, _ := os.ReadFile(files[1].Name())
+	return string(out), string(errOut)
+}
+
+// useFakeAPI points the CLI's config at api.
+func useFakeAPI(t *testing.T, fail ...string) *fakeAPI {
+	api, opts := newFakeAPI(t, fail...)
+	path := filepath.Join(t.TempDir(), "config.toml")
+	config := "[profiles.default]\napi_key = \"test-key\"\nbase_url = \"" + opts.profile.BaseURL + "\"\n"
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(configEnv, path)
+	t.Setenv(profileEnv, "")
+	return api
+}
+
+func TestWithMeta(t *testing.T) {
+	useFakeAPI(t, "bad")
+	for _, tc := range []struct {
+		host    string
+		code    int
+		success bool
+	}{
+		{"192.0.2.1", exitOK, true},
+		// A failed call still prints its result and metadata.
+		{"bad", exitAPIError, false},
+	} {
+		var code int
+		stdout, stderr := capture(t, func() {
+			code = run([]string{"--with-meta", "PortScanner", tc.host})
+		})
+		if code != tc.code {
+			t.Errorf("%s: exit %d, want %d; stderr %s", tc.host, code, tc.code, stderr)
+		}
+		var out struct {
+			Result map[string]interface{} `json:"result"`
+			Meta   map[string]interface{} `json:"meta"`
+		}
+		if err := json.Unmarshal([]byte(stdout), &out); err != nil {
+			t.Fatalf("%s: stdout is not the result and meta: %v\n%s", tc.host, err, stdout)
+		}
+		if out.Result["success"] != tc.success || out.Meta["endpoint"] != "portscanner" ||
+			out.Meta["attempts"] != float64(1) || out.Meta["http_status"] != float64(200) {
+			t.Errorf("%s: stdout %s", tc.host, stdout)
+		}
+		if params, _ := out.Meta["params"].(map[string]interface{}); params["key"] != "REDACTED" {
+			t.Errorf("%s: key not redacted: %v", tc.host, params)
+		}
+		if (stderr == "") != tc.success {
+			t.Errorf("%s: stderr %q", tc.host, stderr)
+		}
+	}
+}
+
+func TestWithMetaOutputFormats(t *testing.T) {
+	useFakeAPI(t)
+	var code int
+	stdout, stderr := capture(t, func() {
+		code = run([]string{"--with-meta", "--output", "template", "--template",
+			"{{.meta.endpoint}} {{.result.open_ports}}", "PortScanner", "192.0.2.1"})
+	})
+	if code != exitOK || stdout != "portscanner [80]\n" {
+		t.Errorf("exit %d, stdout %q, stderr %q", code, stdout, stderr)
+	}
+}
+
+func TestParseMethodArgs(t *testing.T) {
+	checkPort := c99.LookupMethod("CheckPort")
+	for _, tc := range []struct {
+		args []string
+		want *methodArgs
+		call []string // the resolved arguments
+		err  string
+	}{
+		{args: []string{"example.com", "443"},
+			want: &methodArgs{Positional: []string{"example.com", "443"}, Named: map[string]string{}},
+			call: []string{"example.com", "443"}},
+		{args: []string{"--port=443", "--host", "example.com"},
+			want: &methodArgs{Named: map[string]string{"host": "example.com", "port": "443"}},
+			call: []string{"example.com", "443"}},
+		// Named values take their parameter; positional ones fill the rest.
+		{args: []string{"--Port", "443", "example.com"},
+			want: &methodArgs{Positional: []string{"example.com"}, Named: map[string]string{"port": "443"}},
+			call: []string{"example.com", "443"}},
+		{args: []string{"example.com", "--param", "port=22"},
+			want: &methodArgs{Positional: []string{"example.com"}, Named: map[string]string{"port": "22"}},
+			call: []string{"example.com", "22"}},
+		{args: []string{"--host", "a", "--", "--odd"},
+			want: &methodArgs{Positional: []string{"--odd"}, Named: map[string]string{"host": "a"}},
+			call: []string{"a", "--odd"}},
+		{args: []string{"--help"},
+			want: &methodArgs{Named: map[string]string{}, Help: true}},
+		// A value given both ways leaves a positional argument over.
+		{args: []string{"example.com", "443", "--host", "other.com"},
+			err: "too many arguments for method 'CheckPort'"},
+		{args: []string{"example.com", "--port"}, err: "flag --port needs a value"},
+		{args: []string{"--param", "port"}, err: `--param expects key=value, got "port"`},
+		{args: []string{"--timeout", "5"}, err: "unknown parameter 'timeout' for method 'CheckPort'"},
+		{args: []string{"--port", "1", "--port=2"}, err: "parameter 'port' given more than once"},
+		{args: []string{"example.com"}, err: "missing required parameter 'port' for method 'CheckPort'"},
+	} {
+		got, err := parseMethodArgs(checkPort, tc.args)
+		var call []string
+		if err == nil && !got.Help {
+			call, err = checkPort.ResolveArgs(got.Positional, got.Named, nil)
+		}
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("%q: error %v, want %q", tc.args, err, tc.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tc.want) || !reflect.DeepEqual(call, tc.call) {
+			t.Errorf("%q: got %+v, %q, %v; want %+v, %q", tc.args, got, call, err, tc.want, tc.call)
+		}
+	}
+}
+
+func TestParseGlobalFlags(t *testing.T) {
+	for _, tc := range []struct {
+		args     []string
+		output   string
+		template string
+		rest     []string
+	}{
+		{[]string{"-o", "yaml", "CheckPort", "example.com", "--output=csv", "443"},
+			"csv", "", []string{"CheckPort", "example.com", "443"}},
+		// After the method name, a method parameter shadows the global flag.
+		{[]string{"LicenseKeyGenerator", "--template", "XXXX", "-o", "yaml"},
+			"yaml", "", []string{"LicenseKeyGenerator", "--template", "XXXX"}},
+		{[]string{"--template", "{{.keys}}", "LicenseKeyGenerator", "XXXX"},
+			"template", "{{.keys}}", []string{"LicenseKeyGenerator", "XXXX"}},
+		{[]string{"CheckPort", "--", "--output", "json"},
+			"", "", []string{"CheckPort", "--", "--output", "json"}},
+	} {
+		opts, rest, err := parseGlobalFlags(tc.args)
+		if err != nil || opts.Output != tc.output || opts.Template != tc.template || !reflect.DeepEqual(rest, tc.rest) {
+			t.Errorf("%q: output %q, template %q, rest %q, %v", tc.args, opts.Output, opts.Template, rest, err)
+		}
+	}
+}
+
+func TestCallExitCodes(t *testing.T) {
+	api := useFakeAPI(t, "bad")
+	api.status["busy"] = http.StatusServiceUnavailable
+	api.status["quota"] = http.StatusTooManyRequests
+	for _, tc := range []struct {
+		host string
+		code int
+		msg  string
+	}{
+		{"192.0.2.1", exitOK, ""},
+		{"bad", exitAPIError, "Host unreachable"},
+		{"busy", exitTransport, "503 Service Unavailable: Service Unavailable"},
+		{"quota", exitQuota, "429 Too Many Requests: Too Many Requests"},
+	} {
+		var code int
+		stdout, stderr := capture(t, func() { code = run([]string{"PortScanner", tc.host}) })
+		if code != tc.code || !strings.Contains(stderr, tc.msg) {
+			t.Errorf("%s: exit %d, stderr %q; want exit %d", tc.host, code, stderr, tc.code)
+		}
+		if tc.code == exitTransport && stdout != "" {
+			t.Errorf("%s: printed the error body as a result: %s", tc.host, stdout)
+		}
+	}
+}
+
+func TestUnknownMethod(t *testing.T) {
+	useFakeAPI(t)
+	var code int
+	_, stderr := capture(t, func() { code = run([]string{"PortScaner", "192.0.2.1"}) })
+	if code != exitUnknownMethod || !strings.Contains(stderr, `method "PortScaner" not found`) ||
+		!strings.Contains(stderr, "Did you mean: PortScanner, CheckPort?") {
+		t.Errorf("exit %d, stderr %q", code, stderr)
+	}
+}
+
+func TestSuggestMethods(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		want []string
+	}{
+		// Closest first: PortScanner by name, CheckPort by its endpoint.
+		{"PortScaner", []string{"PortScanner", "CheckPort"}},
+		{"geo-ip", []string{"GeoIP"}},
+		{"Dictonary", []string{"Dictionary"}},
+		{"getsubdomians", []string{"GetSubDomains"}},
+		{"whios", []string{"WhoisChecker"}},
+		// Up to len/3 edits are allowed, and never fewer than two.
+		{"pnig", []string{"Ping"}},
+		{"pingxx", []string{"Ping"}},
+		{"pingxxx", nil},
+		{"xyzzyplugh", nil},
+		// Substrings of three or more letters match too.
+		{"bitcoin", []string{"BitcoinBalance"}},
+		{"ab", nil},
+	} {
+		if got := suggestMethods(tc.name); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("suggestMethods(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+	if got := suggestMethods("checker"); len(got) != 3 {
+		t.Errorf("suggestMethods(checker) = %q, want three suggestions", got)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0}, {"abc", "", 3}, {"", "abc", 3}, {"ping", "ping", 0},
+		{"pnig", "ping", 2}, {"kitten", "sitting", 3}, {"geoip", "geoipp", 1},
+	} {
+		if got := editDistance(tc.a, tc.b); got != tc.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"c99"
+)
+
+// completeCommand is the hidden command the completion scripts call. It
+// takes the words typed so far, the last one being the word under the
+// cursor, and prints one candidate per line. Answering from the registry
+// at completion time means new methods complete without regenerating the
+// scripts.
+const completeCommand = "__complete"
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// commandValueFlags and commandSwitches are the built-in commands' own
+// flags, for completion.
+var (
+	commandValueFlags = map[string][]string{
+		"batch":       {"--input", "--parallel", "--out", "--checkpoint", "--resume"},
+		"watch":       {"--interval", "--count", "--on-change"},
+		"conformance": {"--schemas", "--record", "--replay"},
+	}
+	commandSwitches = map[string][]string{
+		"batch":       {"--keep-order", "--csv", "--header"},
+		"list":        {"--json"},
+		"describe":    {"--json"},
+		"search":      {"--json"},
+		"conformance": {"--update", "--json"},
+	}
+)
+
+// takesMethod lists the built-in commands whose first argument is a method
+// name.
+var takesMethod = map[string]bool{"batch": true, "describe": true, "watch": true}
+
+// completeArgs returns the completions for the last word of args.
+func completeArgs(args []string) []string {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	current := args[len(args)-1]
+	words := args[:len(args)-1]
+
+	// Walk the words before the cursor to find the command and method,
+	// skipping global flags and their values.
+	var command string
+	var method *c99.MethodInfo
+	positional := 0
+	var pendingFlag string
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if pendingFlag != "" {
+			pendingFlag = ""
+			continue
+		}
+		if strings.HasPrefix(w, "-") {
+			if strings.Contains(w, "=") {
+				continue
+			}
+			name := strings.TrimLeft(w, "-")
+			if method != nil && findParam(method, name) >= 0 {
+				pendingFlag = name
+				continue
+			}
+			if f, _, _ := lookupGlobalFlag(w); f != nil && f.Value != "" {
+				pendingFlag = name
+				continue
+			}
+			if slices.Contains(commandValueFlags[command], w) {
+				pendingFlag = name
+			}
+			continue
+		}
+		switch {
+		case command == "":
+			command = w
+			method = c99.LookupMethod(w)
+		case takesMethod[command] && method == nil:
+			method = c99.LookupMethod(w)
+		default:
+			positional++
+		}
+	}
+
+	// Values for "--flag value" and "--flag=value".
+	prefix := ""
+	flagName := pendingFlag
+	if flagName == "" && strings.HasPrefix(current, "-") && strings.Contains(current, "=") {
+		name, value, _ := strings.Cut(current, "=")
+		prefix, flagName, current = name+"=", strings.TrimLeft(name, "-"), value
+	}
+	if flagName != "" {
+		var values []string
+		if method != nil {
+			if idx := findParam(method, flagName); idx >= 0 {
+				values = method.Params[idx].Enum
+			}
+		}
+		if values == nil {
+			if f, _, _ := lookupGlobalFlag("--" + flagName); f != nil {
+				values = f.Values
+			} else if f, _, _ := lookupGlobalFlag("-" + flagName); f != nil {
+				values = f.Values
+			}
+		}
+		return filterPrefix(values, current, prefix)
+	}
+
+	var candidates []string
+	if strings.HasPrefix(current, "-") {
+		if method != nil && command != "describe" {
+			for _, p := range method.Params {
+				candidates = append(candidates, "--"+p.Name)
+			}
+			candidates = append(candidates, "--param", "--help")
+		}
+		candidates = append(candidates, commandValueFlags[command]...)
+		candidates = append(candidates, commandSwitches[command]...)
+		for _, f := range globalFlags {
+			candidates = append(candidates, "--"+f.Name)
+		}
+		return filterPrefix(candidates, current, "")
+	}
+
+	switch {
+	case command == "":
+		candidates = append(candidates, builtinCommands...)
+		candidates = append(candidates, methodNames()...)
+	case command == "completion":
+		if positional == 0 {
+			candidates = completionShells
+		}
+	case takesMethod[command] && method == nil, command == "conformance":
+		candidates = methodNames()
+	case method != nil && command != "batch" && command != "describe":
+		// Positional parameters fill in declaration order.
+		if positional < len(method.Params) {
+			candidates = method.Params[positional].Enum
+		}
+	}
+	return filterPrefix(candidates, current, "")
+}
+
+func methodNames() []string {
+	names := make([]string, len(c99.Methods()))
+	for i, info := range c99.Methods() {
+		names[i] = info.Name
+	}
+	return names
+}
+
+// filterPrefix returns the candidates starting with word, ignoring case,
+// each preceded by prefix.
+func filterPrefix(candidates []string, word, prefix string) []string {
+	var out []string
+	lower := strings.ToLower(word)
+	for _, c := range candidates {
+		if strings.HasPrefix(strings.ToLower(c), lower) {
+			out = append(out, prefix+c)
+		}
+	}
+	return out
+}
+
+// runCompletion implements the completion command and returns the process
+// exit code.
+func runCompletion(args []string) int {
+	if len(args) != 1 || !slices.Contains(completionShells, args[0]) {
+		w, code := os.Stderr, exitUsage
+		if len(args) == 1 && (args[0] == "-h" || args[0] == "--help") {
+			w, code = os.Stdout, exitOK
+		}
+		fmt.Fprintln(w, "Usage: c99_api completion bash|zsh|fish")
+		fmt.Fprintln(w, "Load completion for the current session with, for example:")
+		fmt.Fprintln(w, "  source <(c99_api completion bash)")
+		return code
+	}
+
+	prog := filepath.Base(os.Args[0])
+	fn := "_" + regexp.MustCompile(`[^A-Za-z0-9_]`).ReplaceAllString(prog, "_")
+	switch args[0] {
+	case "bash":
+		fmt.Printf(bashCompletion, fn, completeCommand, prog)
+	case "zsh":
+		fmt.Printf(zshCompletion, prog, fn, completeCommand, prog)
+	case "fish":
+		fmt.Printf(fishCompletion, fn, completeCommand, prog)
+	}
+	return exitOK
+}
+
+const bashCompletion = `# bash completion for c99_api
+%[1]s() {
+    local IFS=$'\n'
+    COMPREPLY=($("${COMP_WORDS[0]}" %[2]s "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F %[1]s %[3]s
+`
+
+const zshCompletion = `#compdef %[1]s
+%[2]s() {
+    local -a candidates
+    candidates=("${(@f)$(${words[1]} %[3]s "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    compadd -a candidates
+}
+compdef %[2]s %[4]s
+`
+
+const fishCompletion = `# fish completion for c99_api
+function %[1]s
+    set -l tokens (commandline -opc) (commandline -ct)
+    $tokens[1] %[2]s $tokens[2..-1] 2>/dev/null
+end
+complete -c %[3]s -f -a '(%[1]s)'
+`
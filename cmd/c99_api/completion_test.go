@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestCompleteArgs(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want []string
+	}{
+		// Method names, ignoring case.
+		{[]string{"port"}, []string{"PortScanner"}},
+		{[]string{"describe", "Curr"}, []string{"CurrencyConverter", "CurrencyRates"}},
+		{[]string{"--output", "json", "ping"}, []string{"Ping"}},
+		{[]string{"completion", ""}, []string{"bash", "zsh", "fish"}},
+		// Enum values, for a named or a positional parameter.
+		{[]string{"WeatherChecker", "--unit", ""}, []string{"C", "F"}},
+		{[]string{"WeatherChecker", "--unit=f"}, []string{"--unit=F"}},
+		{[]string{"WeatherChecker", "Amsterdam", ""}, []string{"C", "F"}},
+		{[]string{"CurrencyConverter", "10", "eu"}, []string{"EUR"}},
+		{[]string{"WeatherChecker", ""}, nil},
+		// Flags, with the method's parameters first.
+		{[]string{"CheckPort", "--h"}, []string{"--host", "--help"}},
+		{[]string{"-o", "y"}, []string{"yaml"}},
+		{[]string{"--output=c"}, []string{"--output=csv"}},
+		{[]string{"batch", "PortScanner", "--k"}, []string{"--keep-order"}},
+		// A method parameter named like a global flag takes its place.
+		{[]string{"LicenseKeyGenerator", "--template", ""}, nil},
+	} {
+		if got := completeArgs(tc.args); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("completeArgs(%q) = %q, want %q", tc.args, got, tc.want)
+		}
+	}
+
+	all := completeArgs(nil)
+	for _, want := range []string{"list", "batch", "completion", "GetSubDomains", "WeatherChecker"} {
+		if !slices.Contains(all, want) {
+			t.Errorf("completeArgs() lacks %q", want)
+		}
+	}
+	if got := completeArgs([]string{"conformance", ""}); len(got) != len(methodNames()) {
+		t.Errorf("conformance completes %d names, want every method", len(got))
+	}
+}
@@ -0,0 +1,537 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"c99"
+	"c99/internal/jsonvalue"
+	"c99/internal/quoted"
+)
+
+// Environment variables selecting the config file and profile.
+const (
+	configEnv  = "C99_CONFIG"
+	profileEnv = "C99_PROFILE"
+)
+
+// Config is the CLI configuration file, ~/.config/c99/config.toml or
+// config.yaml. It holds named profiles, one of which is used per run.
+type Config struct {
+	DefaultProfile string              `json:"default_profile"`
+	Profiles       map[string]*Profile `json:"profiles"`
+}
+
+// Profile holds the settings for one API key and network environment.
+type Profile struct {
+	APIKey     string   `json:"api_key"`
+	APIKeyFile string   `json:"api_key_file"`
+	BaseURL    string   `json:"base_url"`
+	Proxy      string   `json:"proxy"`
+	Timeout    duration `json:"timeout"`
+	Retries    int      `json:"retries"`
+	Output     string   `json:"output"`
+	Cache      struct {
+		Enabled bool     `json:"enabled"`
+		Dir     string   `json:"dir"`
+		TTL     duration `json:"ttl"`
+	} `json:"cache"`
+	// Methods maps a method name to default values for its parameters.
+	Methods map[string]map[string]interface{} `json:"methods"`
+}
+
+// defaultCacheTTL applies when caching is enabled without a ttl.
+const defaultCacheTTL = time.Hour
+
+// duration reads either a Go duration string such as "30s" or a number
+// of seconds.
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch val := v.(type) {
+	case float64:
+		*d = duration(time.Duration(val * float64(time.Second)))
+	case string:
+		parsed, err := time.ParseDuration(val)
+		if err != nil {
+			return err
+		}
+		*d = duration(parsed)
+	default:
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	return nil
+}
+
+// configPaths returns the config files looked for, in order.
+func configPaths() []string {
+	if path := os.Getenv(configEnv); path != "" {
+		return []string{path}
+	}
+	var dirs []string
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".config", "c99"))
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		dir = filepath.Join(dir, "c99")
+		if len(dirs) == 0 || dirs[0] != dir {
+			dirs = append(dirs, dir)
+		}
+	}
+	var paths []string
+	for _, dir := range dirs {
+		for _, name := range []string{"config.toml", "config.yaml", "config.yml"} {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+	return paths
+}
+
+// loadConfig reads the config file at path, or the first one found in the
+// default locations. It returns nil when there is none.
+func loadConfig(path string) (*Config, error) {
+	paths := configPaths()
+	if path != "" {
+		paths = []string{path}
+	}
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if os.IsNotExist(err) && path == "" {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		cfg, err := parseConfig(p, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", p, err)
+		}
+		return cfg, nil
+	}
+	return nil, nil
+}
+
+// parseConfig decodes a TOML or YAML config, chosen by file extension.
+func parseConfig(path string, data []byte) (*Config, error) {
+	var tree map[string]interface{}
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		tree, err = parseTOML(string(data))
+	case ".yaml", ".yml":
+		tree, err = parseYAML(string(data))
+	default:
+		return nil, fmt.Errorf("unknown config format; use .toml or .yaml")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Both parsers produce plain maps, which decode into Config via JSON.
+	raw, err := json.Marshal(tree)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// profile returns the named profile. An empty name selects C99_PROFILE,
+// then default_profile, then a profile called "default" if there is one.
+func (cfg *Config) profile(name string) (*Profile, error) {
+	explicit := name != ""
+	if name == "" {
+		name = os.Getenv(profileEnv)
+		explicit = name != ""
+	}
+	if name == "" {
+		name = cfg.DefaultProfile
+		explicit = name != ""
+	}
+	if name == "" {
+		name = "default"
+	}
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		if explicit {
+			return nil, fmt.Errorf("profile '%s' not found in config", name)
+		}
+		return nil, nil
+	}
+	return p, nil
+}
+
+// apiKey returns the profile's key, reading it from api_key_file if set.
+func (p *Profile) apiKey() (string, error) {
+	if p.APIKey != "" || p.APIKeyFile == "" {
+		return p.APIKey, nil
+	}
+	data, err := os.ReadFile(expandHome(p.APIKeyFile))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// methodDefaults returns the profile's default parameters for a method,
+// keyed by parameter name.
+func (p *Profile) methodDefaults(info *c99.MethodInfo) map[string]string {
+	if p == nil {
+		return nil
+	}
+	defaults := make(map[string]string)
+	for name, params := range p.Methods {
+		if c99.LookupMethod(name) != info {
+			continue
+		}
+		for k, v := range params {
+			if idx := findParam(info, k); idx >= 0 {
+				defaults[info.Params[idx].Name] = jsonvalue.Scalar(v)
+			}
+		}
+	}
+	return defaults
+}
+
+// configure applies the profile's network and cache settings to c.
+func (p *Profile) configure(c *c99.C99) error {
+	if p.BaseURL != "" {
+		c.BaseURL = p.BaseURL
+		if !strings.HasSuffix(c.BaseURL, "/") {
+			c.BaseURL += "/"
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if p.Proxy != "" {
+		proxy, err := url.Parse(p.Proxy)
+		if err != nil {
+			return fmt.Errorf("invalid proxy: %v", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	c.HTTPClient = &http.Client{Transport: transport, Timeout: time.Duration(p.Timeout)}
+	c.Retries = p.Retries
+
+	if p.Cache.Enabled {
+		dir := expandHome(p.Cache.Dir)
+		if dir == "" {
+			base, err := os.UserCacheDir()
+			if err != nil {
+				return err
+			}
+			dir = filepath.Join(base, "c99")
+		}
+		ttl := time.Duration(p.Cache.TTL)
+		if ttl == 0 {
+			ttl = defaultCacheTTL
+		}
+		c.Cache = &c99.FileCache{Dir: dir, TTL: ttl}
+		// A failing cache warns once rather than on every response.
+		var warned sync.Once
+		c.OnCacheError = func(err error) {
+			warned.Do(func() {
+				fmt.Fprintf(os.Stderr, "Warning: responses are not being cached: %v\n", err)
+			})
+		}
+	}
+	return nil
+}
+
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// parseTOML parses the subset of TOML used by config files: tables with
+// dotted or quoted names, key = value pairs with dotted keys, strings,
+// numbers, booleans, single-line arrays and comments.
+func parseTOML(src string) (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+	table := root
+	for n, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(stripComment(line))
+		if line == "" {
+			continue
+		}
+		fail := func(format string, args ...interface{}) error {
+			return fmt.Errorf("line %d: %s", n+1, fmt.Sprintf(format, args...))
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, fail("unsupported table header %s", line)
+			}
+			keys, err := splitTOMLKey(line[1 : len(line)-1])
+			if err != nil {
+				return nil, fail("%v", err)
+			}
+			if table, err = tomlTable(root, keys); err != nil {
+				return nil, fail("%v", err)
+			}
+			continue
+		}
+
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fail("expected key = value")
+		}
+		keys, err := splitTOMLKey(k)
+		if err != nil {
+			return nil, fail("%v", err)
+		}
+		value, err := parseTOMLValue(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fail("%v", err)
+		}
+		parent, err := tomlTable(table, keys[:len(keys)-1])
+		if err != nil {
+			return nil, fail("%v", err)
+		}
+		parent[keys[len(keys)-1]] = value
+	}
+	return root, nil
+}
+
+// stripComment removes a trailing # comment outside of quotes. As in YAML,
+// a # only starts a comment at the start of the line or after whitespace.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func splitTOMLKey(s string) ([]string, error) {
+	var keys []string
+	for _, part := range quoted.SplitLiteral(s, ".") {
+		part = strings.TrimSpace(part)
+		if strings.HasPrefix(part, `"`) {
+			unquoted, err := strconv.Unquote(part)
+			if err != nil {
+				return nil, fmt.Errorf("bad key %s", part)
+			}
+			part = unquoted
+		} else if strings.HasPrefix(part, "'") && strings.HasSuffix(part, "'") && len(part) >= 2 {
+			part = part[1 : len(part)-1]
+		}
+		if part == "" {
+			return nil, fmt.Errorf("empty key in %q", s)
+		}
+		keys = append(keys, part)
+	}
+	return keys, nil
+}
+
+// tomlTable returns the nested table at keys below t, creating it if needed.
+func tomlTable(t map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	for _, k := range keys {
+		next, ok := t[k]
+		if !ok {
+			child := make(map[string]interface{})
+			t[k] = child
+			t = child
+			continue
+		}
+		child, ok := next.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("key %s is not a table", k)
+		}
+		t = child
+	}
+	return t, nil
+}
+
+func parseTOMLValue(s string) (interface{}, error) {
+	switch {
+	case s == "":
+		return nil, fmt.Errorf("missing value")
+	case strings.HasPrefix(s, `"`):
+		return strconv.Unquote(s)
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return nil, fmt.Errorf("unterminated string %s", s)
+		}
+		return s[1 : len(s)-1], nil
+	case strings.HasPrefix(s, "["):
+		if !strings.HasSuffix(s, "]") {
+			return nil, fmt.Errorf("arrays must be on one line")
+		}
+		list := []interface{}{}
+		for _, item := range quoted.SplitLiteral(s[1:len(s)-1], ",") {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+			v, err := parseTOMLValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case s == "true":
+		return true, nil
+	case s == "false":
+		return false, nil
+	}
+	if n, err := strconv.ParseFloat(strings.ReplaceAll(s, "_", ""), 64); err == nil {
+		return n, nil
+	}
+	return nil, fmt.Errorf("invalid value %s", s)
+}
+
+// parseYAML parses the subset of YAML used by config files: nested maps
+// by indentation, scalars, "- item" lists of scalars, [a, b] flow lists
+// and comments.
+func parseYAML(src string) (map[string]interface{}, error) {
+	type yamlLine struct {
+		n      int
+		indent int
+		text   string
+	}
+	var lines []yamlLine
+	for n, raw := range strings.Split(src, "\n") {
+		text := strings.TrimRight(stripComment(raw), " \t\r")
+		if strings.TrimSpace(text) == "" || text == "---" {
+			continue
+		}
+		if strings.Contains(text[:len(text)-len(strings.TrimLeft(text, " \t"))], "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", n+1)
+		}
+		trimmed := strings.TrimLeft(text, " ")
+		lines = append(lines, yamlLine{n + 1, len(text) - len(trimmed), trimmed})
+	}
+
+	var parseBlock func(i, indent int) (interface{}, int, error)
+	parseBlock = func(i, indent int) (interface{}, int, error) {
+		if strings.HasPrefix(lines[i].text, "- ") || lines[i].text == "-" {
+			list := []interface{}{}
+			for i < len(lines) && lines[i].indent == indent && strings.HasPrefix(lines[i].text, "-") {
+				list = append(list, parseYAMLScalar(strings.TrimSpace(lines[i].text[1:])))
+				i++
+			}
+			return list, i, nil
+		}
+
+		m := make(map[string]interface{})
+		for i < len(lines) && lines[i].indent == indent {
+			l := lines[i]
+			k, v, ok := strings.Cut(l.text, ":")
+			if !ok {
+				return nil, i, fmt.Errorf("line %d: expected key: value", l.n)
+			}
+			key := strings.TrimSpace(k)
+			if unquoted, err := strconv.Unquote(key); err == nil {
+				key = unquoted
+			}
+			v = strings.TrimSpace(v)
+			i++
+			if v != "" {
+				m[key] = parseYAMLScalar(v)
+				continue
+			}
+			if i < len(lines) && lines[i].indent > indent {
+				child, next, err := parseBlock(i, lines[i].indent)
+				if err != nil {
+					return nil, next, err
+				}
+				m[key], i = child, next
+				continue
+			}
+			// A list may sit at the same indentation as its key.
+			if i < len(lines) && lines[i].indent == indent && strings.HasPrefix(lines[i].text, "-") {
+				child, next, err := parseBlock(i, indent)
+				if err != nil {
+					return nil, next, err
+				}
+				m[key], i = child, next
+				continue
+			}
+			m[key] = nil
+		}
+		if i < len(lines) && lines[i].indent > indent {
+			return nil, i, fmt.Errorf("line %d: unexpected indentation", lines[i].n)
+		}
+		return m, i, nil
+	}
+
+	if len(lines) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	tree, i, err := parseBlock(0, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if i < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[i].n)
+	}
+	m, ok := tree.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("top level must be a mapping")
+	}
+	return m, nil
+}
+
+func parseYAMLScalar(s string) interface{} {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		if v, err := strconv.Unquote(s); err == nil {
+			return v
+		}
+	case strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") && len(s) >= 2:
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	case strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]"):
+		list := []interface{}{}
+		for _, item := range quoted.SplitLiteral(s[1:len(s)-1], ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, parseYAMLScalar(item))
+			}
+		}
+		return list
+	}
+	switch strings.ToLower(s) {
+	case "true", "yes", "on":
+		return true
+	case "false", "no", "off":
+		return false
+	case "null", "~":
+		return nil
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return n
+	}
+	return s
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"c99"
+)
+
+func TestParseTOML(t *testing.T) {
+	got, err := parseTOML(`# C99 config
+default_profile = "work" # trailing comment
+
+[profiles.work]
+api_key = "abc#123"
+base_url = 'https://api.example.com/#not-a-comment'
+timeout = "30s"
+retries = 1_000
+enabled = true
+tags = ["a", 'b,c', 3, ]
+cache.ttl = 60
+
+[profiles.work.methods.PortScanner]
+host = "192.0.2.1"
+
+[profiles."dotted.name"]
+quoted = "tab\tand \"quotes\""
+'literal.key'.x = 'C:\dir'
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"default_profile": "work",
+		"profiles": map[string]interface{}{
+			"work": map[string]interface{}{
+				"api_key":  "abc#123",
+				"base_url": "https://api.example.com/#not-a-comment",
+				"timeout":  "30s",
+				"retries":  float64(1000),
+				"enabled":  true,
+				"tags":     []interface{}{"a", "b,c", float64(3)},
+				"cache":    map[string]interface{}{"ttl": float64(60)},
+				"methods": map[string]interface{}{
+					"PortScanner": map[string]interface{}{"host": "192.0.2.1"},
+				},
+			},
+			"dotted.name": map[string]interface{}{
+				"quoted":      "tab\tand \"quotes\"",
+				"literal.key": map[string]interface{}{"x": `C:\dir`},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %#v\nwant %#v", got, want)
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	for _, tc := range []struct{ src, err string }{
+		{"[[profiles]]", "line 1: unsupported table header"},
+		{"[profiles", "line 1: unsupported table header"},
+		{"\n[profiles..x]", "line 2: empty key"},
+		{"key", "line 1: expected key = value"},
+		{"key =", "line 1: missing value"},
+		{"key = bare", "line 1: invalid value bare"},
+		{"key = 'open", "line 1: unterminated string"},
+		{`key = "open`, "line 1: invalid syntax"},
+		{"key = [1,\n2]", "line 1: arrays must be on one line"},
+		{"a = 1\n[a.b]", "line 2: key a is not a table"},
+		{"a = 1\na.b = 2", "line 2: key a is not a table"},
+		{`"bad\q" = 1`, "line 1: bad key"},
+	} {
+		_, err := parseTOML(tc.src)
+		if err == nil || !strings.HasPrefix(err.Error(), tc.err) {
+			t.Errorf("parseTOML(%q) error = %v, want %q", tc.src, err, tc.err)
+		}
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	got, err := parseYAML(`---
+# C99 config
+default_profile: work
+profiles:
+  work:
+    api_key: "abc#123"   # trailing comment
+    base_url: https://api.example.com/#fragment
+    name: 'it''s'
+    timeout: 30s
+    retries: 3
+    cache:
+      enabled: yes
+      ttl: ~
+    tags: [a, "b, c", 3]
+    words: [it's, 'x, y']
+    hosts:
+    - 192.0.2.1
+    - "192.0.2.2"
+    methods:
+      PortScanner:
+        host: 192.0.2.1
+  "empty":
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"default_profile": "work",
+		"profiles": map[string]interface{}{
+			"work": map[string]interface{}{
+				"api_key":  "abc#123",
+				"base_url": "https://api.example.com/#fragment",
+				"name":     "it's",
+				"timeout":  "30s",
+				"retries":  float64(3),
+				"cache":    map[string]interface{}{"enabled": true, "ttl": nil},
+				"tags":     []interface{}{"a", "b, c", float64(3)},
+				"words":    []interface{}{"it's", "x, y"},
+				"hosts":    []interface{}{"192.0.2.1", "192.0.2.2"},
+				"methods": map[string]interface{}{
+					"PortScanner": map[string]interface{}{"host": "192.0.2.1"},
+				},
+			},
+			"empty": nil,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %#v\nwant %#v", got, want)
+	}
+
+	if got, err := parseYAML("# nothing\n"); err != nil || len(got) != 0 {
+		t.Errorf("empty file = %v, %v", got, err)
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	for _, tc := range []struct{ src, err string }{
+		{"a: 1\n\tb: 2", "line 2: tabs are not allowed"},
+		{"a: 1\njust text", "line 2: expected key: value"},
+		{"a: 1\n  b: 2", "line 2: unexpected indentation"},
+		{"a:\n  b: 1\n    c: 2", "line 3: unexpected indentation"},
+		{"  a: 1\nb: 2", "line 2: unexpected indentation"},
+		{"- a\n- b", "top level must be a mapping"},
+	} {
+		_, err := parseYAML(tc.src)
+		if err == nil || !strings.HasPrefix(err.Error(), tc.err) {
+			t.Errorf("parseYAML(%q) error = %v, want %q", tc.src, err, tc.err)
+		}
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	toml := `default_profile = "work"
+[profiles.work]
+api_key = "k"
+timeout = 2.5
+cache.enabled = true
+cache.ttl = "10m"
+[profiles.work.methods.port-scanner]
+ip = "192.0.2.1"
+`
+	yaml := `default_profile: work
+profiles:
+  work:
+    api_key: k
+    timeout: 2.5
+    cache:
+      enabled: true
+      ttl: 10m
+    methods:
+      port-scanner:
+        ip: 192.0.2.1
+`
+	for _, tc := range []struct{ path, src string }{{"c.toml", toml}, {"c.YAML", yaml}, {"c.yml", yaml}} {
+		cfg, err := parseConfig(tc.path, []byte(tc.src))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.path, err)
+		}
+		p := cfg.Profiles["work"]
+		if cfg.DefaultProfile != "work" || p == nil || p.APIKey != "k" ||
+			time.Duration(p.Timeout) != 2500*time.Millisecond ||
+			!p.Cache.Enabled || time.Duration(p.Cache.TTL) != 10*time.Minute {
+			t.Errorf("%s: got %+v", tc.path, p)
+			continue
+		}
+		defaults := p.methodDefaults(c99.LookupMethod("PortScanner"))
+		if !reflect.DeepEqual(defaults, map[string]string{"ip": "192.0.2.1"}) {
+			t.Errorf("%s: methodDefaults = %v", tc.path, defaults)
+		}
+	}
+
+	for _, tc := range []struct{ path, src, err string }{
+		{"c.json", "{}", "unknown config format"},
+		{"c.toml", "[profiles.work]\ntimeout = \"soon\"", "time: invalid duration"},
+		{"c.toml", "[profiles.work]\ntimeout = true", "invalid duration true"},
+		{"c.toml", "profiles = 1", "json: cannot unmarshal"},
+		{"c.yaml", "a: 1\n  b: 2", "line 2"},
+	} {
+		_, err := parseConfig(tc.path, []byte(tc.src))
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("parseConfig(%s, %q) error = %v, want %q", tc.path, tc.src, err, tc.err)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(path, []byte("key = \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(path); err == nil || !strings.HasPrefix(err.Error(), path+": line 1: missing value") {
+		t.Errorf("loadConfig error = %v, want the path and line", err)
+	}
+	if _, err := loadConfig(filepath.Join(dir, "missing.toml")); !os.IsNotExist(err) {
+		t.Errorf("loadConfig of an explicit missing file = %v, want not exist", err)
+	}
+	t.Setenv(configEnv, filepath.Join(dir, "missing.toml"))
+	if cfg, err := loadConfig(""); cfg != nil || err != nil {
+		t.Errorf("loadConfig with no file = %v, %v; want nil, nil", cfg, err)
+	}
+}
+
+func TestProfile(t *testing.T) {
+	cfg := &Config{Profiles: map[string]*Profile{"default": {APIKey: "d"}, "work": {APIKey: "w"}}}
+	t.Setenv(profileEnv, "")
+	for _, tc := range []struct {
+		name, env, def string
+		want           string
+		err            bool
+	}{
+		{"", "", "", "d", false},
+		{"work", "", "", "w", false},
+		{"", "work", "", "w", false},
+		{"", "", "work", "w", false},
+		{"", "", "missing", "", true},
+		{"missing", "", "", "", true},
+	} {
+		t.Setenv(profileEnv, tc.env)
+		cfg.DefaultProfile = tc.def
+		p, err := cfg.profile(tc.name)
+		if tc.err != (err != nil) || err == nil && p.APIKey != tc.want {
+			t.Errorf("profile(%q) with env %q, default %q = %+v, %v", tc.name, tc.env, tc.def, p, err)
+		}
+	}
+	if p, err := (&Config{}).profile(""); p != nil || err != nil {
+		t.Errorf("no default profile = %+v, %v; want nil, nil", p, err)
+	}
+}
+
+func TestConfigureCacheWarning(t *testing.T) {
+	_, opts := newFakeAPI(t)
+	p := opts.profile
+	// A file where the cache directory should be makes every store fail.
+	p.Cache.Enabled = true
+	p.Cache.Dir = filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(p.Cache.Dir, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c := c99.NewC99("test-key")
+	if err := p.configure(c); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr := capture(t, func() {
+		for range 3 {
+			if _, err := c.PortScanner(context.Background(), "192.0.2.1"); err != nil {
+				t.Errorf("a failing cache failed the call: %v", err)
+			}
+		}
+	})
+	if strings.Count(stderr, "Warning: responses are not being cached") != 1 {
+		t.Errorf("stderr = %q; want one warning", stderr)
+	}
+}
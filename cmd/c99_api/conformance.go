@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"c99"
+)
+
+// conformanceOptions holds the flags of the conformance command.
+type conformanceOptions struct {
+	// Schemas is the directory holding <Method>.schema.json files.
+	Schemas string
+	// Record, if set, is a directory to save each response in.
+	Record string
+	// Replay, if set, is a directory of recorded responses to check
+	// instead of calling the API.
+	Replay string
+	// Update writes schemas inferred from the responses instead of
+	// checking them.
+	Update bool
+	JSON   bool
+}
+
+// conformanceSkip lists methods left out unless named explicitly, because
+// calling them creates something on the C99 side.
+var conformanceSkip = map[string]bool{
+	"LinkBackup":   true,
+	"URLShortener": true,
+	"IPLogger":     true,
+}
+
+// conformanceReport is the outcome of checking one method.
+type conformanceReport struct {
+	Method   string `json:"method"`
+	Endpoint string `json:"endpoint"`
+	// Status is "ok", "drift", "updated", "no_schema", "skipped" or
+	// "error".
+	Status string            `json:"status"`
+	Drift  []c99.SchemaDrift `json:"drift,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+func printConformanceUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: c99_api conformance [--schemas <dir>] [--record <dir>] [--replay <dir>]")
+	fmt.Fprintln(w, "                           [--update] [--json] [method...]")
+	fmt.Fprintln(w, "Calls each method with the first of its examples, or replays recorded")
+	fmt.Fprintln(w, "responses, and checks the responses against JSON Schemas, reporting fields")
+	fmt.Fprintln(w, "that were added, removed or changed type. Without method names, every method")
+	fmt.Fprintln(w, "is checked except "+strings.Join(slices.Sorted(maps.Keys(conformanceSkip)), ", ")+".")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --schemas <dir>  directory of <Method>.schema.json files (default schemas)")
+	fmt.Fprintln(w, "  --record <dir>   save each response as <dir>/<Method>.json")
+	fmt.Fprintln(w, "  --replay <dir>   check the responses saved by --record instead of calling the API")
+	fmt.Fprintln(w, "  --update         write schemas inferred from the responses instead of checking")
+	fmt.Fprintln(w, "  --json           print the reports as JSON")
+	fmt.Fprintln(w, "A list that was empty in the response gets \"items\": {}, which admits any")
+	fmt.Fprintln(w, "element; edit such schemas by hand to describe the elements.")
+}
+
+// parseConformanceFlags pulls the conformance command's own flags out of
+// args.
+func parseConformanceFlags(args []string) (*conformanceOptions, []string, error) {
+	opts := &conformanceOptions{Schemas: "schemas"}
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		needValue := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("flag %s needs a value", name)
+			}
+			i++
+			return args[i], nil
+		}
+
+		var err error
+		switch name {
+		case "--schemas":
+			opts.Schemas, err = needValue()
+		case "--record":
+			opts.Record, err = needValue()
+		case "--replay":
+			opts.Replay, err = needValue()
+		case "--update":
+			opts.Update = true
+		case "--json":
+			opts.JSON = true
+		default:
+			if strings.HasPrefix(args[i], "-") {
+				err = fmt.Errorf("unknown flag %s", args[i])
+			} else {
+				rest = append(rest, args[i])
+			}
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if opts.Record != "" && opts.Replay != "" {
+		return nil, nil, errors.New("--record and --replay cannot be combined")
+	}
+	return opts, rest, nil
+}
+
+// sampleArgs returns the arguments of a method's first example, resolved
+// like a command line.
+func sampleArgs(info *c99.MethodInfo, defaults map[string]string) ([]string, error) {
+	if len(info.Examples) == 0 {
+		return nil, errors.New("no example to call it with")
+	}
+	words, err := splitShellWords(info.Examples[0])
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parseMethodArgs(info, words[1:])
+	if err != nil {
+		return nil, err
+	}
+	return info.ResolveArgs(parsed.Positional, parsed.Named, defaults)
+}
+
+// runConformance implements the conformance command and returns the
+// process exit code: exitOK if every response matched its schema.
+func runConformance(opts *cliOptions, args []string) int {
+	if len(args) > 0 && (args[0] == "-h" || args[0] == "--help") {
+		printConformanceUsage(os.Stdout)
+		return exitOK
+	}
+	copts, names, err := parseConformanceFlags(args)
+	if err != nil {
+		return opts.report(usageError(err, printConformanceUsage))
+	}
+	var infos []*c99.MethodInfo
+	for _, name := range names {
+		info := c99.LookupMethod(name)
+		if info == nil {
+			return opts.report(unknownMethodError(name))
+		}
+		infos = append(infos, info)
+	}
+	if len(infos) == 0 {
+		for _, info := range c99.Methods() {
+			if !conformanceSkip[info.Name] {
+				infos = append(infos, info)
+			}
+		}
+	}
+
+	client, err := opts.newClient()
+	if err != nil {
+		return opts.report(usageError(err, nil))
+	}
+	if copts.Replay == "" && !opts.DryRun && opts.APIKey == "" {
+		return opts.report(errNoAPIKey)
+	}
+	// Responses are recorded as sent and normalized for checking, so that
+	// replays follow the current normalization rules.
+	client.Raw, client.Cache = true, nil
+
+	var reports []conformanceReport
+	counts := make(map[string]int)
+	for _, info := range infos {
+		if opts.DryRun {
+			callArgs, err := sampleArgs(info, opts.methodDefaults(info))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: skipped: %v\n", info.Name, err)
+				continue
+			}
+			req, err := dryRun(client, info, callArgs)
+			if err == nil {
+				err = opts.printDryRun(req)
+			}
+			if err != nil {
+				return opts.report(err)
+			}
+			continue
+		}
+
+		rep := checkConformance(client, opts, copts, info)
+		if rep == nil {
+			// The quota ran out; checking the rest would fail the same way.
+			return exitQuota
+		}
+		counts[rep.Status]++
+		reports = append(reports, *rep)
+		if !copts.JSON {
+			printConformanceReport(os.Stdout, rep)
+		}
+	}
+	if opts.DryRun {
+		return exitOK
+	}
+
+	if copts.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(reports); err != nil {
+			return opts.report(fmt.Errorf("writing output: %v", err))
+		}
+	}
+	var summary []string
+	for _, status := range []string{"ok", "updated", "drift", "no_schema", "skipped", "error"} {
+		if counts[status] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[status], strings.ReplaceAll(status, "_", " ")))
+		}
+	}
+	fmt.Fprintf(os.Stderr, "conformance: %s\n", strings.Join(summary, ", "))
+	if counts["drift"]+counts["no_schema"]+counts["error"] > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
+
+// checkConformance fetches or replays one method's response and checks it
+// against its schema. It returns nil after reporting an exhausted quota.
+func checkConformance(c *c99.C99, opts *cliOptions, copts *conformanceOptions, info *c99.MethodInfo) *conformanceReport {
+	rep := &conformanceReport{Method: info.Name, Endpoint: info.Endpoint}
+	fail := func(status string, err error) *conformanceReport {
+		rep.Status, rep.Error = status, err.Error()
+		return rep
+	}
+
+	var raw map[string]interface{}
+	if copts.Replay != "" {
+		data, err := os.ReadFile(filepath.Join(copts.Replay, info.Name+".json"))
+		if errors.Is(err, os.ErrNotExist) {
+			return fail("skipped", errors.New("no recorded response"))
+		}
+		if err == nil {
+			err = json.Unmarshal(data, &raw)
+		}
+		if err != nil {
+			return fail("error", err)
+		}
+	} else {
+		callArgs, err := sampleArgs(info, opts.methodDefaults(info))
+		if err != nil {
+			return fail("skipped", err)
+		}
+		raw, err = info.Call(context.Background(), c, callArgs)
+		if err != nil && callExit(err) == exitQuota {
+			opts.report(callError(info.Name, err))
+			return nil
+		}
+		if err != nil {
+			return fail("error", err)
+		}
+		if copts.Record != "" {
+			if err := writeJSONFile(filepath.Join(copts.Record, info.Name+".json"), raw); err != nil {
+				return fail("error", err)
+			}
+		}
+	}
+	var result interface{} = raw
+	if !opts.Raw {
+		result = c99.Normalize(info.Name, raw)
+	}
+
+	schemaPath := filepath.Join(copts.Schemas, info.Name+".schema.json")
+	if copts.Update {
+		if err := writeJSONFile(schemaPath, c99.InferSchema(result)); err != nil {
+			return fail("error", err)
+		}
+		rep.Status = "updated"
+		return rep
+	}
+	data, err := os.ReadFile(schemaPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return fail("no_schema", fmt.Errorf("no schema at %s; run with --update to create it", schemaPath))
+	}
+	var schema c99.Schema
+	if err == nil {
+		err = json.Unmarshal(data, &schema)
+	}
+	if err != nil {
+		return fail("error", fmt.Errorf("reading %s: %v", schemaPath, err))
+	}
+	rep.Drift = schema.Validate(result)
+	rep.Status = "ok"
+	if len(rep.Drift) > 0 {
+		rep.Status = "drift"
+	}
+	return rep
+}
+
+func printConformanceReport(w io.Writer, rep *conformanceReport) {
+	status := rep.Status
+	if rep.Error != "" {
+		status += ": " + rep.Error
+	}
+	fmt.Fprintf(w, "%-24s %-22s %s\n", rep.Method, rep.Endpoint, status)
+	for _, d := range rep.Drift {
+		fmt.Fprintf(w, "    %s\n", d)
+	}
+}
+
+// writeJSONFile writes v as indented JSON, creating the directory.
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
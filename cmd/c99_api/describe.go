@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"c99"
+)
+
+// parseDescribeFlags splits the arguments of list and describe into
+// positional words and the --json switch.
+func parseDescribeFlags(args []string) (words []string, asJSON bool, err error) {
+	for _, arg := range args {
+		switch {
+		case arg == "--json":
+			asJSON = true
+		case strings.HasPrefix(arg, "-"):
+			return nil, false, fmt.Errorf("unknown flag %s", arg)
+		default:
+			words = append(words, arg)
+		}
+	}
+	return words, asJSON, nil
+}
+
+// writeRegistry writes registry entries, or other structured values, as
+// JSON, or in the output format if one was chosen. Formats other than JSON
+// work on the decoded form.
+func writeRegistry(opts *cliOptions, asJSON bool, v interface{}) error {
+	o := *opts
+	if asJSON {
+		o.Output = "json"
+	}
+	if o.Output != "json" && o.Output != "ndjson" {
+		var err error
+		if v, err = jsonValue(v); err != nil {
+			return err
+		}
+	}
+	return writeResult(os.Stdout, &o, v)
+}
+
+// jsonValue returns v as decoded JSON, made of the maps, lists and scalars
+// every output format can render.
+func jsonValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+// runList implements the list command and returns the process exit code.
+func runList(opts *cliOptions, args []string) int {
+	words, asJSON, err := parseDescribeFlags(args)
+	if err == nil && len(words) > 0 {
+		err = errors.New("list takes no arguments")
+	}
+	if err != nil {
+		return opts.report(usageError(err, func(w io.Writer) {
+			fmt.Fprintln(w, "Usage: c99_api list [--json]")
+		}))
+	}
+
+	if asJSON || opts.Output != "" {
+		if err := writeRegistry(opts, asJSON, c99.Methods()); err != nil {
+			return opts.report(err)
+		}
+		return exitOK
+	}
+	printMethodList(os.Stdout, c99.Methods())
+	return exitOK
+}
+
+// printMethodList writes methods grouped by category.
+func printMethodList(w io.Writer, infos []*c99.MethodInfo) {
+	shown := 0
+	group := func(title string, match func(info *c99.MethodInfo) bool) {
+		first := true
+		for _, info := range infos {
+			if !match(info) {
+				continue
+			}
+			if first {
+				if shown > 0 {
+					fmt.Fprintln(w)
+				}
+				fmt.Fprintf(w, "%s:\n", title)
+				first = false
+			}
+			fmt.Fprintf(w, "  %-24s %s\n", info.Name, info.Description)
+			shown++
+		}
+	}
+	known := make(map[string]bool)
+	for _, cat := range c99.Categories() {
+		known[cat.Name] = true
+		group(cat.Title, func(info *c99.MethodInfo) bool { return info.Category == cat.Name })
+	}
+	group("Other", func(info *c99.MethodInfo) bool { return !known[info.Category] })
+}
+
+// searchMethods returns the methods matching every one of terms, ignoring
+// case. A term matches when the method name contains it, or when a word of
+// the endpoint, description, category or tags starts with it.
+func searchMethods(terms []string) []*c99.MethodInfo {
+	var found []*c99.MethodInfo
+	for _, info := range c99.Methods() {
+		words := strings.FieldsFunc(strings.ToLower(strings.Join([]string{
+			info.Endpoint, info.Description, info.Category, strings.Join(info.Tags, " "),
+		}, " ")), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		matched := true
+		for _, term := range terms {
+			if !strings.Contains(c99.FoldName(info.Name), c99.FoldName(term)) && !hasWordPrefix(words, strings.ToLower(term)) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			found = append(found, info)
+		}
+	}
+	return found
+}
+
+func hasWordPrefix(words []string, prefix string) bool {
+	for _, w := range words {
+		if strings.HasPrefix(w, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// runSearch implements the search command and returns the process exit
+// code.
+func runSearch(opts *cliOptions, args []string) int {
+	terms, asJSON, err := parseDescribeFlags(args)
+	if err == nil && len(terms) == 0 {
+		err = errors.New("search needs a term")
+	}
+	if err != nil {
+		return opts.report(usageError(err, func(w io.Writer) {
+			fmt.Fprintln(w, "Usage: c99_api search <term>... [--json]")
+		}))
+	}
+
+	found := searchMethods(terms)
+	if asJSON || opts.Output != "" {
+		if found == nil {
+			found = []*c99.MethodInfo{}
+		}
+		if err := writeRegistry(opts, asJSON, found); err != nil {
+			return opts.report(err)
+		}
+		return exitOK
+	}
+	if len(found) == 0 {
+		fmt.Fprintf(os.Stderr, "No methods match '%s'\n", strings.Join(terms, " "))
+		return exitOK
+	}
+	printMethodList(os.Stdout, found)
+	return exitOK
+}
+
+// runDescribe implements the describe command and returns the process exit
+// code.
+func runDescribe(opts *cliOptions, args []string) int {
+	words, asJSON, err := parseDescribeFlags(args)
+	if err == nil && len(words) != 1 {
+		err = errors.New("describe takes one method name")
+	}
+	if err != nil {
+		return opts.report(usageError(err, func(w io.Writer) {
+			fmt.Fprintln(w, "Usage: c99_api describe <method> [--json]")
+		}))
+	}
+
+	info := c99.LookupMethod(words[0])
+	if info == nil {
+		return opts.report(unknownMethodError(words[0]))
+	}
+	if asJSON || opts.Output != "" {
+		if err := writeRegistry(opts, asJSON, info); err != nil {
+			return opts.report(err)
+		}
+		return exitOK
+	}
+	printMethodUsage(os.Stdout, info)
+	return exitOK
+}
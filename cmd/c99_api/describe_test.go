@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"c99"
+)
+
+func TestDescribeJSON(t *testing.T) {
+	useFakeAPI(t)
+	var code int
+	stdout, stderr := capture(t, func() { code = run([]string{"describe", "weather-checker", "--json"}) })
+	if code != exitOK {
+		t.Fatalf("exit %d, stderr %s", code, stderr)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(stdout), &fields); err != nil {
+		t.Fatalf("stdout is not a JSON object: %v\n%s", err, stdout)
+	}
+	var keys []string
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	if want := []string{"category", "description", "endpoint", "examples", "name", "params", "tags"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys %q, want %q", keys, want)
+	}
+	var params []map[string]interface{}
+	if err := json.Unmarshal(fields["params"], &params); err != nil {
+		t.Fatal(err)
+	}
+	wantParams := []map[string]interface{}{
+		{"name": "location", "type": "string", "required": true},
+		{"name": "unit", "type": "string", "required": false, "default": "C", "values": []interface{}{"C", "F"}},
+	}
+	if !reflect.DeepEqual(params, wantParams) {
+		t.Errorf("params %v, want %v", params, wantParams)
+	}
+
+	var got c99.MethodInfo
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := *c99.LookupMethod("WeatherChecker")
+	want.Call = nil
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("describe --json = %+v, want %+v", got, want)
+	}
+}
+
+func TestListJSON(t *testing.T) {
+	useFakeAPI(t)
+	stdout, _ := capture(t, func() { run([]string{"list", "--json"}) })
+	var infos []c99.MethodInfo
+	if err := json.Unmarshal([]byte(stdout), &infos); err != nil {
+		t.Fatalf("stdout is not a JSON list: %v", err)
+	}
+	if len(infos) != len(c99.Methods()) || infos[0].Name != c99.Methods()[0].Name {
+		t.Errorf("list --json gave %d methods, want %d in registry order", len(infos), len(c99.Methods()))
+	}
+}
+
+func TestDescribeErrors(t *testing.T) {
+	useFakeAPI(t)
+	for _, tc := range []struct {
+		args []string
+		code int
+	}{
+		{[]string{"describe", "Nope", "--json"}, exitUnknownMethod},
+		{[]string{"describe"}, exitUsage},
+		{[]string{"describe", "Ping", "GeoIP"}, exitUsage},
+		{[]string{"describe", "Ping", "--yaml"}, exitUsage},
+		{[]string{"list", "extra"}, exitUsage},
+	} {
+		var code int
+		stdout, _ := capture(t, func() { code = run(tc.args) })
+		if code != tc.code || stdout != "" {
+			t.Errorf("%q: exit %d, stdout %q; want exit %d", tc.args, code, stdout, tc.code)
+		}
+	}
+}
+
+func TestSearchMethods(t *testing.T) {
+	for _, tc := range []struct {
+		terms []string
+		want  []string
+	}{
+		// A substring of the name matches.
+		{[]string{"ort"}, []string{"PortScanner", "CheckPort", "URLShortener"}},
+		{[]string{"get-sub"}, []string{"GetSubDomains"}},
+		// Other fields match by word prefix: "addr" starts "address", but
+		// "ddress" starts no word.
+		{[]string{"ADDR", "bitcoin"}, []string{"BitcoinBalance"}},
+		{[]string{"ddress"}, nil},
+		{[]string{"tcp"}, []string{"CheckPort"}},
+		{[]string{"forex"}, []string{"CurrencyConverter", "CurrencyRates"}},
+		// Every term must match.
+		{[]string{"email", "spam"}, []string{"DisposableMailCheck"}},
+		{[]string{"port", "crypto"}, nil},
+	} {
+		var got []string
+		for _, info := range searchMethods(tc.terms) {
+			got = append(got, info.Name)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("searchMethods(%q) = %q, want %q", tc.terms, got, tc.want)
+		}
+	}
+}
+
+func TestPrintMethodList(t *testing.T) {
+	var b strings.Builder
+	printMethodList(&b, []*c99.MethodInfo{
+		c99.LookupMethod("CurrencyRates"),
+		{Name: "Custom", Description: "Not in a category."},
+		c99.LookupMethod("PortScanner"),
+		c99.LookupMethod("CheckPort"),
+	})
+	want := `Domain and network reconnaissance:
+  PortScanner              Scan ports on a given IP address.
+  CheckPort                Check if a specific port is open on a given host.
+`
+	if !strings.HasPrefix(b.String(), want) || !strings.HasSuffix(b.String(), "\nOther:\n  Custom                   Not in a category.\n") {
+		t.Errorf("printMethodList =\n%s", b.String())
+	}
+	if strings.Count(b.String(), "CurrencyRates") != 1 {
+		t.Errorf("CurrencyRates not listed once:\n%s", b.String())
+	}
+}
+
+func TestSearchCommand(t *testing.T) {
+	useFakeAPI(t)
+	stdout, stderr := capture(t, func() { run([]string{"search", "xyzzy"}) })
+	if stdout != "" || stderr != "No methods match 'xyzzy'\n" {
+		t.Errorf("stdout %q, stderr %q", stdout, stderr)
+	}
+	stdout, _ = capture(t, func() { run([]string{"search", "xyzzy", "--json"}) })
+	if stdout != "[]\n" {
+		t.Errorf("search --json with no match = %q, want []", stdout)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"c99"
+	"c99/internal/redact"
+)
+
+// creditsPerCall is used to estimate the cost of a batch dry run. It
+// assumes every call is billed as one request.
+const creditsPerCall = 1
+
+// dryRun calls info with args on a copy of c that captures the request
+// instead of sending it.
+func dryRun(c *c99.C99, info *c99.MethodInfo, args []string) (*http.Request, error) {
+	var req *http.Request
+	dc := *c
+	dc.DryRun = func(r *http.Request) { req = r }
+	if _, err := info.Call(context.Background(), &dc, args); err != nil {
+		return nil, err
+	}
+	if req == nil {
+		return nil, fmt.Errorf("method '%s' made no request", info.Name)
+	}
+	return req, nil
+}
+
+// describeRequest returns the HTTP method, URL, endpoint and parameters of
+// req, with the API key redacted.
+func describeRequest(req *http.Request) map[string]interface{} {
+	params := make(map[string]interface{})
+	for k, v := range req.URL.Query() {
+		params[k] = v[0]
+	}
+	if _, ok := params["key"]; ok {
+		params["key"] = "REDACTED"
+	}
+	return map[string]interface{}{
+		"method":   req.Method,
+		"url":      redact.URL(req.URL),
+		"endpoint": path.Base(req.URL.Path),
+		"params":   params,
+	}
+}
+
+// curlCommand returns a curl command line that repeats req. The API key is
+// read from $C99_API_KEY so the command can be shared as is.
+func curlCommand(req *http.Request) string {
+	u := *req.URL
+	query := u.Query()
+	u.RawQuery = ""
+
+	names := make([]string, 0, len(query))
+	for k := range query {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("curl -sS -G " + shellQuote(u.String()))
+	for _, k := range names {
+		b.WriteString(" \\\n  --data-urlencode ")
+		if k == "key" {
+			b.WriteString(`"key=$` + apiKeyEnv + `"`)
+		} else {
+			b.WriteString(shellQuote(k + "=" + query.Get(k)))
+		}
+	}
+	return b.String()
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// printDryRun writes the request a single call would make, as a curl
+// command with --as-curl and in the output format otherwise.
+func (o *cliOptions) printDryRun(req *http.Request) error {
+	if o.AsCurl {
+		_, err := fmt.Println(curlCommand(req))
+		return err
+	}
+	return writeResult(os.Stdout, o, describeRequest(req))
+}
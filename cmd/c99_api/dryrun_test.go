@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"c99"
+)
+
+// dryRunRequest returns the request method would make with args.
+func dryRunRequest(t *testing.T, method string, args ...string) map[string]interface{} {
+	t.Helper()
+	c := c99.NewC99("secret-key")
+	req, err := dryRun(c, c99.LookupMethod(method), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := describeRequest(req)
+	desc["curl"] = curlCommand(req)
+	return desc
+}
+
+func TestDescribeRequest(t *testing.T) {
+	desc := dryRunRequest(t, "CurrencyConverter", "10", "USD", "EUR")
+	curl := desc["curl"].(string)
+	delete(desc, "curl")
+	want := map[string]interface{}{
+		"method":   "GET",
+		"url":      "https://api.c99.nl/currency?amount=10&from=USD&json=true&key=REDACTED&to=EUR",
+		"endpoint": "currency",
+		"params":   map[string]interface{}{"amount": "10", "from": "USD", "json": "true", "key": "REDACTED", "to": "EUR"},
+	}
+	if !reflect.DeepEqual(desc, want) {
+		t.Errorf("describeRequest = %v, want %v", desc, want)
+	}
+	wantCurl := `curl -sS -G 'https://api.c99.nl/currency' \
+  --data-urlencode 'amount=10' \
+  --data-urlencode 'from=USD' \
+  --data-urlencode 'json=true' \
+  --data-urlencode "key=$C99_API_KEY" \
+  --data-urlencode 'to=EUR'`
+	if curl != wantCurl {
+		t.Errorf("curlCommand =\n%s\nwant\n%s", curl, wantCurl)
+	}
+}
+
+func TestCurlCommandQuoting(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh to run the command with")
+	}
+	city := `O'Hare "$HOME" \ $(id) ; &`
+	curl := dryRunRequest(t, "WeatherChecker", city, "C")["curl"].(string)
+	if strings.Contains(curl, "secret-key") {
+		t.Fatalf("the key leaked into %s", curl)
+	}
+	// Run the command with printf in place of curl, to see the arguments
+	// the shell would pass.
+	cmd := exec.Command(sh, "-c", `printf '%s\n'`+strings.TrimPrefix(curl, "curl"))
+	cmd.Env = []string{"C99_API_KEY=from-env"}
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("sh -c %s: %v", curl, err)
+	}
+	args := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	if !contains(args, "location="+city) || !contains(args, "key=from-env") {
+		t.Errorf("the shell passed %q", args)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDryRunCommand(t *testing.T) {
+	api := useFakeAPI(t)
+	for _, flag := range []string{"--dry-run", "--as-curl"} {
+		var code int
+		stdout, stderr := capture(t, func() { code = run([]string{flag, "CurrencyConverter", "10", "USD", "EUR"}) })
+		if code != exitOK || strings.Contains(stdout, "test-key") || !strings.Contains(stdout, "/currency") {
+			t.Errorf("%s: exit %d, stdout %s, stderr %s", flag, code, stdout, stderr)
+		}
+	}
+	if hosts := api.takeHosts(); len(hosts) != 0 {
+		t.Errorf("a dry run called the API: %q", hosts)
+	}
+}
+
+func TestBatchDryRun(t *testing.T) {
+	// The third row has too many fields for CheckPort.
+	in := writeInput(t, "192.0.2.1,443\n192.0.2.2,22\n192.0.2.3,80,extra\n")
+	for _, asCurl := range []bool{false, true} {
+		api, opts := newFakeAPI(t)
+		opts.APIKey = "secret-key"
+		opts.DryRun, opts.AsCurl = true, asCurl
+		var code int
+		stdout, stderr := capture(t, func() {
+			code = runBatch(opts, []string{"CheckPort", "-i", in, "--csv", "--keep-order"})
+		})
+		if code != exitBatchPartial {
+			t.Errorf("as curl %v: exit %d, want %d for the invalid row", asCurl, code, exitBatchPartial)
+		}
+		if !strings.HasPrefix(stderr, "batch dry run: 2 calls, about 2 credits, 1 invalid rows in ") {
+			t.Errorf("as curl %v: stderr %q", asCurl, stderr)
+		}
+		if strings.Contains(stdout, "secret-key") {
+			t.Errorf("as curl %v: the key leaked into %s", asCurl, stdout)
+		}
+		lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+		if asCurl {
+			if strings.Count(stdout, "curl -sS") != 2 || !strings.HasPrefix(lines[len(lines)-1], "# 2: ") {
+				t.Errorf("as curl: stdout\n%s", stdout)
+			}
+		} else {
+			recs := parseRecords(t, stdout)
+			if len(recs) != 3 || recs[0].Request == nil || recs[2].Error == "" {
+				t.Errorf("records %+v", recs)
+			}
+		}
+		if hosts := api.takeHosts(); len(hosts) != 0 {
+			t.Errorf("as curl %v: a dry run called the API: %q", asCurl, hosts)
+		}
+	}
+}
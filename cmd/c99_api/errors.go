@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"c99"
+)
+
+// Exit codes of the CLI. Scripts depend on them, so existing values must
+// not change.
+const (
+	exitOK            = 0
+	exitFailure       = 1 // any error not covered below
+	exitUsage         = 2 // bad command line, flags or config file
+	exitUnknownMethod = 3
+	exitTransport     = 4 // network failure, HTTP error status or unreadable response
+	exitAPIError      = 5 // the API answered success:false
+	exitQuota         = 6 // the API key has no requests left
+	exitBatchPartial  = 7 // some batch calls failed
+)
+
+// errorCodes name the exit codes in --error-format json output.
+var errorCodes = map[int]string{
+	exitFailure:       "error",
+	exitUsage:         "usage",
+	exitUnknownMethod: "unknown_method",
+	exitTransport:     "transport",
+	exitAPIError:      "api_error",
+	exitQuota:         "quota_exceeded",
+	exitBatchPartial:  "batch_partial_failure",
+}
+
+var errorFormats = []string{"text", "json"}
+
+// cliError is an error together with the exit code it ends the program
+// with.
+type cliError struct {
+	Exit int
+	Err  error
+	// Method is the method being run, if known.
+	Method string
+	// Suggestions lists similar method names for an unknown method.
+	Suggestions []string
+	// Hint, if set, prints help after the message in text format.
+	Hint func(w io.Writer)
+}
+
+func (e *cliError) Error() string { return e.Err.Error() }
+func (e *cliError) Unwrap() error { return e.Err }
+
+// usageError reports a bad command line. Errors that already carry an exit
+// code keep it.
+func usageError(err error, hint func(w io.Writer)) *cliError {
+	var e *cliError
+	if errors.As(err, &e) {
+		return e
+	}
+	return &cliError{Exit: exitUsage, Err: err, Hint: hint}
+}
+
+// errNoAPIKey is reported when a call is about to be made without a key.
+var errNoAPIKey = usageError(fmt.Errorf("no API key given; pass it as the first argument, with --apikey, in %s or in a config profile", apiKeyEnv), nil)
+
+// methodUsageError reports bad arguments to a method, followed by its usage.
+func methodUsageError(info *c99.MethodInfo, err error) *cliError {
+	e := usageError(err, func(w io.Writer) { printMethodUsage(w, info) })
+	e.Method = info.Name
+	return e
+}
+
+func unknownMethodError(name string) *cliError {
+	suggestions := suggestMethods(name)
+	return &cliError{
+		Exit:        exitUnknownMethod,
+		Err:         fmt.Errorf("method %q not found", name),
+		Method:      name,
+		Suggestions: suggestions,
+		Hint: func(w io.Writer) {
+			if len(suggestions) > 0 {
+				fmt.Fprintf(w, "Did you mean: %s?\n", strings.Join(suggestions, ", "))
+			}
+			fmt.Fprintln(w, "Use 'list' to see all available methods")
+		},
+	}
+}
+
+// callError wraps an error returned by calling method.
+func callError(method string, err error) *cliError {
+	return &cliError{Exit: callExit(err), Err: err, Method: method}
+}
+
+// callExit returns the exit code for an error returned by a method call.
+func callExit(err error) int {
+	var apiErr *c99.APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.QuotaExceeded() {
+			return exitQuota
+		}
+		return exitAPIError
+	}
+	var statusErr *c99.StatusError
+	if errors.As(err, &statusErr) && statusErr.QuotaExceeded() {
+		return exitQuota
+	}
+	return exitTransport
+}
+
+// errorRecord is the JSON form of an error written with --error-format json.
+type errorRecord struct {
+	Code        string   `json:"code"`
+	ExitCode    int      `json:"exit_code"`
+	Message     string   `json:"message"`
+	Method      string   `json:"method,omitempty"`
+	Endpoint    string   `json:"endpoint,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// report writes err to stderr in the chosen error format and returns the
+// exit code for it. Errors other than *cliError exit with exitFailure.
+func (o *cliOptions) report(err error) int {
+	var e *cliError
+	if !errors.As(err, &e) {
+		e = &cliError{Exit: exitFailure, Err: err}
+	}
+	if o.ErrorFormat == "json" {
+		rec := errorRecord{
+			Code:        errorCodes[e.Exit],
+			ExitCode:    e.Exit,
+			Message:     e.Err.Error(),
+			Method:      e.Method,
+			Suggestions: e.Suggestions,
+		}
+		var apiErr *c99.APIError
+		var statusErr *c99.StatusError
+		switch {
+		case errors.As(e.Err, &apiErr):
+			if apiErr.Message != "" {
+				rec.Message = apiErr.Message
+			}
+			rec.Endpoint = apiErr.Endpoint
+		case errors.As(e.Err, &statusErr):
+			rec.Endpoint = statusErr.Endpoint
+		}
+		line, _ := json.Marshal(map[string]errorRecord{"error": rec})
+		os.Stderr.Write(append(line, '\n'))
+		return e.Exit
+	}
+	fmt.Fprintf(os.Stderr, "Error: %v\n", e.Err)
+	if e.Hint != nil {
+		e.Hint(os.Stderr)
+	}
+	return e.Exit
+}
+
+func printExitCodes(w io.Writer) {
+	fmt.Fprintln(w, "Exit codes:")
+	fmt.Fprintln(w, "  0  success")
+	fmt.Fprintln(w, "  1  other error, e.g. writing output failed")
+	fmt.Fprintln(w, "  2  usage error: bad arguments, flags or config file")
+	fmt.Fprintln(w, "  3  unknown method")
+	fmt.Fprintln(w, "  4  transport error: network failure, HTTP error status or unreadable response")
+	fmt.Fprintln(w, "  5  the API reported a failure (success:false)")
+	fmt.Fprintln(w, "  6  the API key's request quota is exhausted, or HTTP 429")
+	fmt.Fprintln(w, "  7  some batch calls failed")
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"c99/internal/jsonvalue"
+)
+
+// outputFormats lists the values accepted by --output.
+var outputFormats = []string{"json", "ndjson", "yaml", "table", "csv", "template"}
+
+// writeResult renders a decoded API result in the format selected by opts.
+func writeResult(w io.Writer, opts *cliOptions, result interface{}) error {
+	switch opts.Output {
+	case "", "json":
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "ndjson":
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "yaml":
+		return writeYAML(w, result)
+	case "table":
+		return writeTable(w, result)
+	case "csv":
+		return writeCSV(w, result)
+	case "template":
+		return writeTemplate(w, opts.Template, result)
+	}
+	return fmt.Errorf("unknown output format '%s'", opts.Output)
+}
+
+// tabulate flattens a result into rows for table and CSV output. When the
+// result holds a list, such as the subdomains from GetSubDomains or the
+// ports from PortScanner, each element becomes a row; otherwise the result
+// itself is a single row. Nested objects become dotted column names.
+func tabulate(result interface{}) ([]string, [][]string) {
+	var records []map[string]string
+	if key, list := jsonvalue.PrimaryList(result); list != nil {
+		for _, item := range list {
+			record := make(map[string]string)
+			if obj, ok := item.(map[string]interface{}); ok {
+				flattenInto(record, "", obj)
+			} else {
+				record[key] = jsonvalue.Scalar(item)
+			}
+			records = append(records, record)
+		}
+	} else {
+		record := make(map[string]string)
+		if obj, ok := result.(map[string]interface{}); ok {
+			flattenInto(record, "", obj)
+		} else {
+			record["value"] = jsonvalue.Scalar(result)
+		}
+		records = append(records, record)
+	}
+
+	seen := make(map[string]bool)
+	var header []string
+	for _, record := range records {
+		for col := range record {
+			if !seen[col] {
+				seen[col] = true
+				header = append(header, col)
+			}
+		}
+	}
+	sort.Strings(header)
+
+	rows := make([][]string, len(records))
+	for i, record := range records {
+		row := make([]string, len(header))
+		for j, col := range header {
+			row[j] = record[col]
+		}
+		rows[i] = row
+	}
+	return header, rows
+}
+
+// flattenInto copies obj into record, joining nested keys with dots. Lists
+// of scalars are joined with ";", other lists are kept as JSON.
+func flattenInto(record map[string]string, prefix string, obj map[string]interface{}) {
+	for k, v := range obj {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch val := v.(type) {
+		case map[string]interface{}:
+			flattenInto(record, key, val)
+		case []interface{}:
+			record[key] = joinList(val)
+		default:
+			record[key] = jsonvalue.Scalar(val)
+		}
+	}
+}
+
+// joinList renders a list as ";"-separated scalars, or as JSON when it
+// holds nested values.
+func joinList(list []interface{}) string {
+	parts := make([]string, len(list))
+	for i, item := range list {
+		switch item.(type) {
+		case map[string]interface{}, []interface{}:
+			data, _ := json.Marshal(list)
+			return string(data)
+		}
+		parts[i] = jsonvalue.Scalar(item)
+	}
+	return strings.Join(parts, ";")
+}
+
+func writeTable(w io.Writer, result interface{}) error {
+	header, rows := tabulate(result)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	upper := make([]string, len(header))
+	for i, col := range header {
+		upper[i] = strings.ToUpper(col)
+	}
+	fmt.Fprintln(tw, strings.Join(upper, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, result interface{}) error {
+	header, rows := tabulate(result)
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// templateFuncs are available to --template in addition to the built-ins.
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join": func(sep string, list []interface{}) string {
+		parts := make([]string, len(list))
+		for i, item := range list {
+			parts[i] = jsonvalue.Scalar(item)
+		}
+		return strings.Join(parts, sep)
+	},
+}
+
+func writeTemplate(w io.Writer, text string, result interface{}) error {
+	tmpl, err := template.New("output").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, result); err != nil {
+		return err
+	}
+	out := b.String()
+	if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	_, err = io.WriteString(w, out)
+	return err
+}
+
+// writeYAML writes a decoded JSON value as a YAML document.
+func writeYAML(w io.Writer, v interface{}) error {
+	var b strings.Builder
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(val) == 0 {
+			b.WriteString("{}\n")
+		} else {
+			yamlMap(&b, val, 0)
+		}
+	case []interface{}:
+		if len(val) == 0 {
+			b.WriteString("[]\n")
+		} else {
+			yamlList(&b, val, 0)
+		}
+	default:
+		b.WriteString(yamlScalar(val) + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func yamlMap(b *strings.Builder, m map[string]interface{}, indent int) {
+	pad := strings.Repeat("  ", indent)
+	for _, k := range jsonvalue.SortedKeys(m) {
+		b.WriteString(pad + yamlString(k) + ":")
+		yamlValue(b, m[k], indent)
+	}
+}
+
+func yamlList(b *strings.Builder, list []interface{}, indent int) {
+	pad := strings.Repeat("  ", indent)
+	for _, item := range list {
+		// Non-empty maps and lists start on the dash line, e.g. "- key: v".
+		var nested strings.Builder
+		switch val := item.(type) {
+		case map[string]interface{}:
+			if len(val) > 0 {
+				yamlMap(&nested, val, indent+1)
+			}
+		case []interface{}:
+			if len(val) > 0 {
+				yamlList(&nested, val, indent+1)
+			}
+		}
+		if nested.Len() > 0 {
+			b.WriteString(pad + "- " + strings.TrimPrefix(nested.String(), pad+"  "))
+			continue
+		}
+		b.WriteString(pad + "-")
+		yamlValue(b, item, indent)
+	}
+}
+
+// yamlValue writes the value following a "key:" or "-" marker.
+func yamlValue(b *strings.Builder, v interface{}, indent int) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(val) == 0 {
+			b.WriteString(" {}\n")
+			return
+		}
+		b.WriteString("\n")
+		yamlMap(b, val, indent+1)
+	case []interface{}:
+		if len(val) == 0 {
+			b.WriteString(" []\n")
+			return
+		}
+		b.WriteString("\n")
+		yamlList(b, val, indent+1)
+	default:
+		b.WriteString(" " + yamlScalar(val) + "\n")
+	}
+}
+
+func yamlScalar(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return yamlString(val)
+	}
+	return jsonvalue.Scalar(v)
+}
+
+// yamlNumber matches, in lower case, the strings that YAML 1.1 or 1.2
+// reads as an int or a float: decimal, 0b, 0o, 0x and leading-zero octal
+// ints, underscores between digits, floats with exponents, .inf and .nan.
+var yamlNumber = regexp.MustCompile(`^[-+]?(?:0b[01_]+|0o[0-7_]+|0x[0-9a-f_]+|` +
+	`[0-9][0-9_]*(?:\.[0-9_]*)?(?:e[-+]?[0-9]+)?|\.[0-9][0-9_]*(?:e[-+]?[0-9]+)?|\.inf)$|^\.nan$`)
+
+// yamlString quotes s when it would otherwise be read back as something
+// other than the same string.
+func yamlString(s string) string {
+	if s == "" {
+		return `""`
+	}
+	lower := strings.ToLower(s)
+	switch lower {
+	case "true", "false", "yes", "no", "y", "n", "on", "off", "null", "~":
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil || yamlNumber.MatchString(lower) {
+		return strconv.Quote(s)
+	}
+	if strings.ContainsAny(s, ":#{}[],&*!|>'\"%@`\n\t") || s[0] == '-' || s[0] == '?' ||
+		s[0] == ' ' || s[len(s)-1] == ' ' {
+		return strconv.Quote(s)
+	}
+	return s
+}
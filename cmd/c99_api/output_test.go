@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// decodeResult decodes a result the way the client does.
+func decodeResult(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("bad fixture %s: %v", s, err)
+	}
+	return v
+}
+
+const subdomainsFixture = `{"success":true,"subdomains":[
+	{"subdomain":"a.example.com","ip":"192.0.2.1","cloudflare":true,"ports":[80,443]},
+	{"subdomain":"b.example.com","ip":"none","cloudflare":false,"meta":{"source":"crt.sh"}}]}`
+
+func TestTabulate(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		result string
+		header []string
+		rows   [][]string
+	}{
+		{"subdomains", subdomainsFixture,
+			[]string{"cloudflare", "ip", "meta.source", "ports", "subdomain"},
+			[][]string{
+				{"true", "192.0.2.1", "", "80;443", "a.example.com"},
+				{"false", "none", "crt.sh", "", "b.example.com"},
+			}},
+		{"ports", `{"success":true,"open_ports":[80,443]}`,
+			[]string{"open_ports"}, [][]string{{"80"}, {"443"}}},
+		{"single object", `{"success":true,"headers":{"Server":"nginx"},"count":2}`,
+			[]string{"count", "headers.Server", "success"}, [][]string{{"2", "nginx", "true"}}},
+		{"nested list", `{"records":[{"mx":[{"host":"mx1"}]}]}`,
+			[]string{"mx"}, [][]string{{`[{"host":"mx1"}]`}}},
+		{"scalar", `"pong"`, []string{"value"}, [][]string{{"pong"}}},
+	} {
+		header, rows := tabulate(decodeResult(t, tc.result))
+		if !reflect.DeepEqual(header, tc.header) || !reflect.DeepEqual(rows, tc.rows) {
+			t.Errorf("%s: tabulate = %q, %q; want %q, %q", tc.name, header, rows, tc.header, tc.rows)
+		}
+	}
+}
+
+func TestWriteTableAndCSV(t *testing.T) {
+	for _, tc := range []struct {
+		output, result, want string
+	}{
+		{"table", subdomainsFixture, "" +
+			"CLOUDFLARE  IP         META.SOURCE  PORTS   SUBDOMAIN\n" +
+			"true        192.0.2.1               80;443  a.example.com\n" +
+			"false       none       crt.sh               b.example.com\n"},
+		{"csv", subdomainsFixture, "" +
+			"cloudflare,ip,meta.source,ports,subdomain\n" +
+			"true,192.0.2.1,,80;443,a.example.com\n" +
+			"false,none,crt.sh,,b.example.com\n"},
+		{"table", `{"success":true,"open_ports":[80,443]}`, "OPEN_PORTS\n80\n443\n"},
+		{"csv", `{"success":true,"open_ports":[80,443]}`, "open_ports\n80\n443\n"},
+		{"csv", `{"result":"a, \"b\""}`, "result\n\"a, \"\"b\"\"\"\n"},
+	} {
+		var b strings.Builder
+		if err := writeResult(&b, &cliOptions{Output: tc.output}, decodeResult(t, tc.result)); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != tc.want {
+			t.Errorf("%s of %s =\n%s\nwant\n%s", tc.output, tc.result, b.String(), tc.want)
+		}
+	}
+}
+
+func TestWriteTemplate(t *testing.T) {
+	var b strings.Builder
+	opts := &cliOptions{Output: "template", Template: `{{join "," .open_ports}} {{json .success}} {{.missing}}`}
+	if err := writeResult(&b, opts, decodeResult(t, `{"success":true,"open_ports":[80,443]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != "80,443 true <no value>\n" {
+		t.Errorf("template output = %q", got)
+	}
+}
+
+func TestYAMLString(t *testing.T) {
+	for _, s := range []string{
+		"", "true", "No", "y", "off", "null", "~",
+		"12", "-3", "+4", "1.5", "1e3", "1.", ".5", "1_000",
+		"0x1F", "0o17", "0b101", "017", ".inf", "-.Inf", ".nan", ".NaN",
+		"a: b", "#x", "-x", "?x", " x", "x ", "[x]", "a\nb",
+	} {
+		if got := yamlString(s); got != strconv.Quote(s) {
+			t.Errorf("yamlString(%q) = %s, want it quoted", s, got)
+		}
+	}
+	for _, s := range []string{"example.com", "192.0.2.1", "nginx", "0xZZ", "1.2.3", "v1"} {
+		if got := yamlString(s); got != s {
+			t.Errorf("yamlString(%q) = %s, want it bare", s, got)
+		}
+	}
+}
+
+func TestWriteYAML(t *testing.T) {
+	var b strings.Builder
+	if err := writeYAML(&b, decodeResult(t, subdomainsFixture)); err != nil {
+		t.Fatal(err)
+	}
+	want := `subdomains:
+  - cloudflare: true
+    ip: 192.0.2.1
+    ports:
+      - 80
+      - 443
+    subdomain: a.example.com
+  - cloudflare: false
+    ip: none
+    meta:
+      source: crt.sh
+    subdomain: b.example.com
+success: true
+`
+	if b.String() != want {
+		t.Errorf("writeYAML =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestYAMLRoundTrip(t *testing.T) {
+	// Read back with the config file parser, which handles maps and lists
+	// of scalars. It reads fewer number forms than YAML, so TestYAMLString
+	// checks the quoting of the rest.
+	result := decodeResult(t, `{"success":true,"count":3,"ratio":0.5,"error":null,
+		"strings":["0x1F","0o17",".inf",".nan","1_000","017","yes","null","1e3","",
+			"a: b","#tag","- dash","say \"hi\""],
+		"nested":{"key with spaces":"v","true":"x","12":"twelve"}}`)
+	var b strings.Builder
+	if err := writeYAML(&b, result); err != nil {
+		t.Fatal(err)
+	}
+	back, err := parseYAML(b.String())
+	if err != nil {
+		t.Fatalf("parseYAML:\n%s\n%v", b.String(), err)
+	}
+	if !reflect.DeepEqual(back, result) {
+		t.Errorf("round trip of\n%s\ngave %#v\nwant %#v", b.String(), back, result)
+	}
+}
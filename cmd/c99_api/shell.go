@@ -0,0 +1,745 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"c99"
+	"c99/internal/jsonvalue"
+)
+
+// historyFile is where the shell keeps its command history, relative to
+// the user's home directory.
+const historyFile = ".c99_history"
+
+const maxHistory = 1000
+
+// errHelpShown ends a pipeline after a method's --help was printed.
+var errHelpShown = errors.New("help shown")
+
+var shellCommands = []string{"help", "list", "search", "vars", "history", "output", "exit", "quit"}
+
+// shell is the state of an interactive "c99_api shell" session.
+type shell struct {
+	client  *c99.C99
+	opts    *cliOptions
+	vars    map[string]interface{}
+	history []string
+	editor  *lineEditor
+}
+
+func printShellHelp() {
+	fmt.Println(`Commands:
+  <method> [args...]          call a method; arguments work as on the command line
+  $name = <command>           store a result in a variable
+  $name[.path]                print a variable, or part of it
+  <command> | <command> ...   pipe a result into the next method as its first
+                              argument; a list runs the method once per element
+  <command> | .path           select part of a result, e.g. | .subdomains[].ip
+  help [method]               show this help, or a method's parameters
+  list                        list methods by category
+  search <term>...            find methods by name, description or tag
+  vars                        list variables
+  history                     show command history
+  output <format>             change the output format (` + strings.Join(outputFormats[:5], ", ") + `)
+  exit, quit                  leave the shell
+The result of the last command is always available as $last.
+Arguments may refer to variables, e.g. GeoIP $last.subdomains[0].ip, also
+inside double quotes; write '$5' or \$5 for a literal $.`)
+}
+
+// runShell implements the shell command and returns the process exit code.
+func runShell(opts *cliOptions, args []string) int {
+	if len(args) > 0 {
+		if args[0] == "-h" || args[0] == "--help" {
+			fmt.Println("Usage: c99_api shell")
+			printShellHelp()
+			return exitOK
+		}
+		return opts.report(usageError(errors.New("shell takes no arguments"), func(w io.Writer) {
+			fmt.Fprintln(w, "Usage: c99_api shell")
+		}))
+	}
+	if opts.APIKey == "" && !opts.DryRun {
+		return opts.report(errNoAPIKey)
+	}
+
+	client, err := opts.newClient()
+	if err != nil {
+		return opts.report(usageError(err, nil))
+	}
+	sh := &shell{
+		client: client,
+		opts:   opts,
+		vars:   make(map[string]interface{}),
+	}
+	sh.loadHistory()
+	sh.editor = newLineEditor(os.Stdin, os.Stdout, sh.complete)
+	fmt.Println("C99 interactive shell. Type 'help' for commands, Tab to complete.")
+
+	for {
+		line, err := sh.editor.readLine("c99> ", sh.history)
+		if err != nil {
+			if err == io.EOF {
+				fmt.Println()
+				return exitOK
+			}
+			return opts.report(err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		sh.addHistory(line)
+		if line == "exit" || line == "quit" {
+			return exitOK
+		}
+		if err := sh.execute(line); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
+	}
+}
+
+// execute runs one line of shell input.
+func (sh *shell) execute(line string) error {
+	shellWords, err := splitShellLine(line)
+	if err != nil {
+		return err
+	}
+	if len(shellWords) == 0 {
+		return nil
+	}
+	words := wordTexts(shellWords)
+
+	switch words[0] {
+	case "help":
+		if len(words) > 1 {
+			info := c99.LookupMethod(words[1])
+			if info == nil {
+				return fmt.Errorf("method %q not found", words[1])
+			}
+			printMethodUsage(os.Stdout, info)
+			return nil
+		}
+		printShellHelp()
+		return nil
+	case "list":
+		printMethodList(os.Stdout, c99.Methods())
+		return nil
+	case "search":
+		if len(words) < 2 {
+			return fmt.Errorf("usage: search <term>...")
+		}
+		found := searchMethods(words[1:])
+		if len(found) == 0 {
+			return fmt.Errorf("no methods match '%s'", strings.Join(words[1:], " "))
+		}
+		printMethodList(os.Stdout, found)
+		return nil
+	case "vars":
+		for _, name := range jsonvalue.SortedKeys(sh.vars) {
+			fmt.Printf("  $%s\n", name)
+		}
+		return nil
+	case "history":
+		for i, h := range sh.history {
+			fmt.Printf("%5d  %s\n", i+1, h)
+		}
+		return nil
+	case "output":
+		if len(words) != 2 {
+			return fmt.Errorf("usage: output <format>")
+		}
+		return setGlobalFlag(sh.opts, "output", words[1])
+	}
+
+	target := ""
+	if len(shellWords) >= 3 && shellWords[0].isRef() && shellWords[1].is("=") {
+		target = words[0][1:]
+		if !isIdentifier(target) {
+			return fmt.Errorf("invalid variable name '%s'", words[0])
+		}
+		shellWords = shellWords[2:]
+	}
+
+	result, err := sh.runPipeline(shellWords)
+	if err == errHelpShown {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	sh.vars["last"] = result
+	if target != "" {
+		sh.vars[target] = result
+		return nil
+	}
+	return writeResult(os.Stdout, sh.opts, sh.opts.postProcess(result))
+}
+
+// runPipeline evaluates "|"-separated stages. Each stage is a method call,
+// a variable reference or a path applied to the previous result.
+func (sh *shell) runPipeline(words []shellWord) (interface{}, error) {
+	var stages [][]shellWord
+	start := 0
+	for i, w := range words {
+		if w.is("|") {
+			stages = append(stages, words[start:i])
+			start = i + 1
+		}
+	}
+	stages = append(stages, words[start:])
+	// Check every stage before calling any, so that no credits are spent
+	// on a pipeline that cannot finish.
+	for _, stage := range stages {
+		if len(stage) == 0 {
+			return nil, fmt.Errorf("empty pipeline stage")
+		}
+	}
+
+	var value interface{}
+	for i, stage := range stages {
+		first := stage[0].text
+		switch {
+		case stage[0].isRef() && len(stage) == 1:
+			v, err := sh.lookupVar(first)
+			if err != nil {
+				return nil, err
+			}
+			value = v
+		case strings.HasPrefix(first, ".") && !stage[0].quoted && len(stage) == 1:
+			if i == 0 {
+				return nil, fmt.Errorf("nothing to select from; use $last%s", first)
+			}
+			p, err := c99.ParsePath(first)
+			if err != nil {
+				return nil, err
+			}
+			value = p.Eval(value)
+		default:
+			v, err := sh.callStage(stage, value, i > 0)
+			if err != nil {
+				return nil, err
+			}
+			value = v
+		}
+	}
+	return value, nil
+}
+
+// callStage calls a method. When piped is set, the incoming value becomes
+// the first positional argument; a list calls the method once per element
+// and yields the list of results.
+func (sh *shell) callStage(stage []shellWord, input interface{}, piped bool) (interface{}, error) {
+	name := stage[0].text
+	info := c99.LookupMethod(name)
+	if info == nil {
+		msg := fmt.Sprintf("method %q not found", name)
+		if suggestions := suggestMethods(name); len(suggestions) > 0 {
+			msg += "; did you mean " + strings.Join(suggestions, ", ") + "?"
+		}
+		return nil, errors.New(msg)
+	}
+
+	args := make([]string, 0, len(stage)-1)
+	for _, word := range stage[1:] {
+		arg := word.text
+		if word.isRef() {
+			v, err := sh.lookupVar(arg)
+			if err != nil {
+				return nil, err
+			}
+			arg = jsonvalue.Scalar(v)
+		}
+		args = append(args, arg)
+	}
+	parsed, err := parseMethodArgs(info, args)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Help {
+		printMethodUsage(os.Stdout, info)
+		return nil, errHelpShown
+	}
+
+	call := func(first interface{}) (interface{}, error) {
+		margs := *parsed
+		if piped {
+			margs.Positional = append([]string{jsonvalue.Scalar(first)}, parsed.Positional...)
+		}
+		callArgs, err := info.ResolveArgs(margs.Positional, margs.Named, sh.opts.methodDefaults(info))
+		if err != nil {
+			return nil, err
+		}
+		if sh.opts.DryRun {
+			req, err := dryRun(sh.client, info, callArgs)
+			if err != nil {
+				return nil, err
+			}
+			if sh.opts.AsCurl {
+				return curlCommand(req), nil
+			}
+			return describeRequest(req), nil
+		}
+		return info.Call(context.Background(), sh.client, callArgs)
+	}
+
+	list, isList := input.([]interface{})
+	if !piped || !isList {
+		return call(input)
+	}
+	results := make([]interface{}, 0, len(list))
+	for _, item := range list {
+		r, err := call(item)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// lookupVar resolves a "$name" or "$name.path" reference.
+func (sh *shell) lookupVar(ref string) (interface{}, error) {
+	ref = strings.TrimPrefix(ref, "$")
+	end := strings.IndexAny(ref, ".[")
+	if end < 0 {
+		end = len(ref)
+	}
+	name, path := ref[:end], ref[end:]
+	v, ok := sh.vars[name]
+	if !ok {
+		return nil, fmt.Errorf("undefined variable $%s", name)
+	}
+	if path == "" {
+		return v, nil
+	}
+	p, err := c99.ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return p.Eval(v), nil
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// complete returns completions for the word ending at the cursor: method
+// and shell command names first, then parameter flags and variables.
+func (sh *shell) complete(line string) []string {
+	words := strings.Fields(line)
+	word := ""
+	if len(words) > 0 && !strings.HasSuffix(line, " ") {
+		word = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+	// Only the words of the current pipeline stage matter.
+	for i := len(words) - 1; i >= 0; i-- {
+		if words[i] == "|" || words[i] == "=" {
+			words = words[i+1:]
+			break
+		}
+	}
+
+	var candidates []string
+	switch {
+	case strings.HasPrefix(word, "$"):
+		for name := range sh.vars {
+			candidates = append(candidates, "$"+name)
+		}
+	case len(words) == 0:
+		for _, info := range c99.Methods() {
+			candidates = append(candidates, info.Name)
+		}
+		candidates = append(candidates, shellCommands...)
+	case words[0] == "help":
+		for _, info := range c99.Methods() {
+			candidates = append(candidates, info.Name)
+		}
+	case words[0] == "output":
+		candidates = append(candidates, outputFormats[:5]...)
+	case strings.HasPrefix(word, "-"):
+		if info := c99.LookupMethod(words[0]); info != nil {
+			for _, p := range info.Params {
+				candidates = append(candidates, "--"+p.Name)
+			}
+			candidates = append(candidates, "--help")
+		}
+	}
+
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(strings.ToLower(c), strings.ToLower(word)) {
+			matches = append(matches, c)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+func historyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, historyFile)
+}
+
+func (sh *shell) loadHistory() {
+	path := historyPath()
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			sh.history = append(sh.history, line)
+		}
+	}
+	if len(sh.history) > maxHistory {
+		sh.history = sh.history[len(sh.history)-maxHistory:]
+	}
+}
+
+func (sh *shell) addHistory(line string) {
+	if n := len(sh.history); n > 0 && sh.history[n-1] == line {
+		return
+	}
+	sh.history = append(sh.history, line)
+	path := historyPath()
+	if path == "" {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
+
+// shellWord is a word of shell input. Quoted is set when the word begins
+// with a quoted or escaped character, as in "|": such a word is never a
+// pipe, "=" or a path. As in sh, only single quotes and backslashes also
+// make a leading $ literal, as in '$5' or \$5; literal notes that.
+type shellWord struct {
+	text            string
+	quoted, literal bool
+}
+
+// is reports whether w is the unquoted word s.
+func (w shellWord) is(s string) bool {
+	return !w.quoted && w.text == s
+}
+
+// isRef reports whether w refers to a variable.
+func (w shellWord) isRef() bool {
+	return !w.literal && strings.HasPrefix(w.text, "$")
+}
+
+func wordTexts(words []shellWord) []string {
+	texts := make([]string, len(words))
+	for i, w := range words {
+		texts[i] = w.text
+	}
+	return texts
+}
+
+// splitShellWords splits a line into words, honouring single and double
+// quotes and backslash escapes. An unquoted "|" is always a word of its own.
+func splitShellWords(line string) ([]string, error) {
+	words, err := splitShellLine(line)
+	if err != nil {
+		return nil, err
+	}
+	return wordTexts(words), nil
+}
+
+// splitShellLine is splitShellWords, noting which words are literal.
+func splitShellLine(line string) ([]shellWord, error) {
+	var words []shellWord
+	var cur strings.Builder
+	var word shellWord
+	inWord := false
+	// start begins a word, noting how its first character is quoted: by
+	// '"', by '\'' or a backslash, or not at all.
+	start := func(quote rune) {
+		if !inWord {
+			inWord = true
+			word = shellWord{quoted: quote != 0, literal: quote == '\''}
+		}
+	}
+	end := func() {
+		if inWord {
+			word.text = cur.String()
+			words = append(words, word)
+			cur.Reset()
+			inWord = false
+		}
+	}
+	var quote rune
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else if r == '\\' && quote == '"' && i+1 < len(runes) {
+				i++
+				cur.WriteRune(runes[i])
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			start(r)
+		case r == '\\' && i+1 < len(runes):
+			i++
+			start('\'')
+			cur.WriteRune(runes[i])
+		case r == ' ' || r == '\t':
+			end()
+		case r == '|':
+			end()
+			words = append(words, shellWord{text: "|"})
+		default:
+			start(0)
+			cur.WriteRune(r)
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	end()
+	return words, nil
+}
+
+// lineEditor reads lines with history and tab completion when attached to
+// a terminal, and plain lines otherwise. Terminal mode is switched with
+// stty so no platform-specific code is needed.
+type lineEditor struct {
+	in       *bufio.Reader
+	out      io.Writer
+	terminal bool
+	complete func(line string) []string
+}
+
+func newLineEditor(in *os.File, out io.Writer, complete func(string) []string) *lineEditor {
+	e := &lineEditor{in: bufio.NewReader(in), out: out, complete: complete}
+	if st, err := in.Stat(); err == nil && st.Mode()&os.ModeCharDevice != 0 {
+		_, err := stty("-g")
+		e.terminal = err == nil
+	}
+	return e
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+func (e *lineEditor) readLine(prompt string, history []string) (string, error) {
+	if !e.terminal {
+		fmt.Fprint(e.out, prompt)
+		line, err := e.in.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		return strings.TrimRight(line, "\r\n"), err
+	}
+
+	saved, err := stty("-g")
+	if err != nil {
+		e.terminal = false
+		return e.readLine(prompt, history)
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		e.terminal = false
+		return e.readLine(prompt, history)
+	}
+	defer stty(saved)
+
+	var line []rune
+	pos := 0
+	histIdx := len(history)
+	var draft []rune
+
+	redraw := func() {
+		fmt.Fprintf(e.out, "\r%s%s\x1b[K", prompt, string(line))
+		if back := len(line) - pos; back > 0 {
+			fmt.Fprintf(e.out, "\x1b[%dD", back)
+		}
+	}
+	setLine := func(s []rune) {
+		line = append([]rune(nil), s...)
+		pos = len(line)
+		redraw()
+	}
+	redraw()
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			fmt.Fprint(e.out, "\r\n")
+			return "", err
+		}
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(e.out, "\r\n")
+			return string(line), nil
+		case 3: // Ctrl-C abandons the line
+			fmt.Fprint(e.out, "^C\r\n")
+			line, pos = nil, 0
+			histIdx = len(history)
+			redraw()
+		case 4: // Ctrl-D exits on an empty line
+			if len(line) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+		case 1: // Ctrl-A
+			pos = 0
+			redraw()
+		case 5: // Ctrl-E
+			pos = len(line)
+			redraw()
+		case 21: // Ctrl-U
+			line, pos = line[pos:], 0
+			redraw()
+		case 11: // Ctrl-K
+			line = line[:pos]
+			redraw()
+		case 23: // Ctrl-W deletes the previous word
+			start := pos
+			for start > 0 && line[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && line[start-1] != ' ' {
+				start--
+			}
+			line = append(line[:start], line[pos:]...)
+			pos = start
+			redraw()
+		case 127, 8: // Backspace
+			if pos > 0 {
+				line = append(line[:pos-1], line[pos:]...)
+				pos--
+				redraw()
+			}
+		case '\t':
+			e.completeAt(&line, &pos, prompt)
+			redraw()
+		case 27: // Escape sequences: arrows, Home, End, Delete
+			b1, _, _ := e.in.ReadRune()
+			if b1 != '[' && b1 != 'O' {
+				continue
+			}
+			b2, _, _ := e.in.ReadRune()
+			switch b2 {
+			case 'A':
+				if histIdx > 0 {
+					if histIdx == len(history) {
+						draft = line
+					}
+					histIdx--
+					setLine([]rune(history[histIdx]))
+				}
+			case 'B':
+				if histIdx < len(history) {
+					histIdx++
+					if histIdx == len(history) {
+						setLine(draft)
+					} else {
+						setLine([]rune(history[histIdx]))
+					}
+				}
+			case 'C':
+				if pos < len(line) {
+					pos++
+					redraw()
+				}
+			case 'D':
+				if pos > 0 {
+					pos--
+					redraw()
+				}
+			case 'H':
+				pos = 0
+				redraw()
+			case 'F':
+				pos = len(line)
+				redraw()
+			case '3':
+				e.in.ReadRune() // trailing '~'
+				if pos < len(line) {
+					line = append(line[:pos], line[pos+1:]...)
+					redraw()
+				}
+			}
+		default:
+			if r >= 32 && r != utf8.RuneError {
+				line = append(line[:pos], append([]rune{r}, line[pos:]...)...)
+				pos++
+				redraw()
+			}
+		}
+	}
+}
+
+// completeAt completes the word before the cursor. A single match is
+// inserted in full; several matches are extended to their common prefix,
+// or listed when there is nothing more to insert.
+func (e *lineEditor) completeAt(line *[]rune, pos *int, prompt string) {
+	before := string((*line)[:*pos])
+	matches := e.complete(before)
+	if len(matches) == 0 {
+		return
+	}
+	start := strings.LastIndexAny(before, " |") + 1
+	word := before[start:]
+
+	insert := matches[0]
+	if len(matches) > 1 {
+		for _, m := range matches[1:] {
+			for !strings.HasPrefix(strings.ToLower(m), strings.ToLower(insert)) {
+				insert = insert[:len(insert)-1]
+			}
+		}
+		if len(insert) <= len(word) {
+			fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(matches, "  "))
+			return
+		}
+	} else {
+		insert += " "
+	}
+
+	replaced := []rune(before[:start] + insert)
+	rest := (*line)[*pos:]
+	*line = append(replaced, rest...)
+	*pos = len(replaced)
+}
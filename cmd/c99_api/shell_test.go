@@ -0,0 +1,137 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	for _, tc := range []struct {
+		line string
+		want []string
+	}{
+		{"", []string{}},
+		{"  \t ", []string{}},
+		{"GeoIP 192.0.2.1", []string{"GeoIP", "192.0.2.1"}},
+		{`Translator "hello world"  nl`, []string{"Translator", "hello world", "nl"}},
+		{`a 'it''s' "say \"hi\"" 'back\slash'`, []string{"a", "its", `say "hi"`, `back\slash`}},
+		{`a b\ c \"d`, []string{"a", "b c", `"d`}},
+		{`a ""`, []string{"a", ""}},
+		{`a|b | c`, []string{"a", "|", "b", "|", "c"}},
+		{`a "|" \|`, []string{"a", "|", "|"}},
+		{`x pre"mid"post`, []string{"x", "premidpost"}},
+		{`trailing\`, []string{`trailing\`}},
+	} {
+		got, err := splitShellWords(tc.line)
+		if err != nil || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("splitShellWords(%q) = %q, %v; want %q", tc.line, got, err, tc.want)
+		}
+	}
+	for _, line := range []string{`a "open`, `a 'open`, `a "esc\"`} {
+		if _, err := splitShellWords(line); err == nil || err.Error() != "unterminated quote" {
+			t.Errorf("splitShellWords(%q) error = %v", line, err)
+		}
+	}
+}
+
+func TestSplitShellLine(t *testing.T) {
+	words, err := splitShellLine(`$a "$b" '$c' \$d $e'f' "|" = '='`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var refs, ops []string
+	for _, w := range words {
+		if w.isRef() {
+			refs = append(refs, w.text)
+		}
+		if w.is("|") || w.is("=") {
+			ops = append(ops, w.text)
+		}
+	}
+	if want := []string{"$a", "$b", "$ef"}; !reflect.DeepEqual(refs, want) {
+		t.Errorf("references %q, want %q", refs, want)
+	}
+	if want := []string{"="}; !reflect.DeepEqual(ops, want) {
+		t.Errorf("operators %q, want %q", ops, want)
+	}
+}
+
+func newTestShell(t *testing.T) (*shell, *fakeAPI) {
+	api, opts := newFakeAPI(t, "bad")
+	opts.Output = "ndjson"
+	client, err := opts.newClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &shell{client: client, opts: opts, vars: make(map[string]interface{})}, api
+}
+
+func TestShellSubstitution(t *testing.T) {
+	sh, api := newTestShell(t)
+	sh.vars["hosts"] = []interface{}{"192.0.2.1", "192.0.2.2"}
+	sh.vars["n"] = float64(5)
+
+	for _, tc := range []struct {
+		line  string
+		hosts []string
+		out   string
+	}{
+		{"$scan = PortScanner 192.0.2.1", []string{"192.0.2.1"}, ""},
+		{"$scan.open_ports[0]", nil, "80\n"},
+		{"PortScanner $scan.open_ports[0]", []string{"80"}, `{"open_ports":[80],"success":true}` + "\n"},
+		{`PortScanner "$n"`, []string{"5"}, ""},
+		{`PortScanner '$n'`, []string{"$n"}, ""},
+		{`PortScanner \$n`, []string{"$n"}, ""},
+		{`PortScanner 'a|b'`, []string{"a|b"}, ""},
+		{"$hosts | PortScanner | .[].open_ports[0]", []string{"192.0.2.1", "192.0.2.2"}, "[80,80]\n"},
+		{"PortScanner 192.0.2.3 | .open_ports", []string{"192.0.2.3"}, "[80]\n"},
+		{"$scan | .success", nil, "true\n"},
+		{"$last", nil, "true\n"},
+	} {
+		var err error
+		out, _ := capture(t, func() { err = sh.execute(tc.line) })
+		if err != nil {
+			t.Errorf("%s: %v", tc.line, err)
+		}
+		if hosts := api.takeHosts(); !reflect.DeepEqual(hosts, tc.hosts) {
+			t.Errorf("%s: called %q, want %q", tc.line, hosts, tc.hosts)
+		}
+		if tc.out != "" && out != tc.out {
+			t.Errorf("%s: printed %q, want %q", tc.line, out, tc.out)
+		}
+	}
+	if _, ok := sh.vars["scan"].(map[string]interface{}); !ok {
+		t.Errorf("$scan = %v", sh.vars["scan"])
+	}
+}
+
+func TestShellErrors(t *testing.T) {
+	sh, api := newTestShell(t)
+	for _, tc := range []struct{ line, err string }{
+		{"PortScanner $missing", "undefined variable $missing"},
+		{"$missing.x", "undefined variable $missing"},
+		{"| PortScanner", "empty pipeline stage"},
+		{"PortScanner 192.0.2.1 |", "empty pipeline stage"},
+		{".open_ports", "nothing to select from; use $last.open_ports"},
+		{"$1x = PortScanner 192.0.2.1", "invalid variable name '$1x'"},
+		{"PortScaner 192.0.2.1", "did you mean PortScanner"},
+		{"PortScanner bad", "Host unreachable"},
+		{`PortScanner "open`, "unterminated quote"},
+	} {
+		var err error
+		capture(t, func() { err = sh.execute(tc.line) })
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: error = %v, want %q", tc.line, err, tc.err)
+		}
+	}
+	if hosts := api.takeHosts(); !reflect.DeepEqual(hosts, []string{"bad"}) {
+		t.Errorf("called %q; only the failing call should reach the API", hosts)
+	}
+	// A quoted "=" is an argument, not an assignment.
+	var err error
+	capture(t, func() { err = sh.execute(`$x "=" PortScanner 192.0.2.1`) })
+	if _, ok := sh.vars["x"]; ok || err == nil {
+		t.Errorf("quoted = assigned $x (error %v)", err)
+	}
+}
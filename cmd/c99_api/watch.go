@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"c99"
+)
+
+// watchOptions holds the flags of the watch command.
+type watchOptions struct {
+	Interval time.Duration
+	// Count stops the watch after that many calls; zero runs until
+	// interrupted.
+	Count int
+	// OnChange is a shell command run after each change.
+	OnChange string
+}
+
+// watchEvent is the NDJSON line written by the watch command: the first
+// result, the changes since the previous result, or a failed call.
+type watchEvent struct {
+	Time      string       `json:"time"`
+	Run       int          `json:"run"`
+	Result    interface{}  `json:"result,omitempty"`
+	Changes   []c99.Change `json:"changes,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	ErrorCode string       `json:"error_code,omitempty"`
+}
+
+func printWatchUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: c99_api watch [--interval <duration>] [--count <n>] [--on-change <command>]")
+	fmt.Fprintln(w, "                     <method> [args...]")
+	fmt.Fprintln(w, "Calls the method repeatedly and writes one NDJSON line with the first result,")
+	fmt.Fprintln(w, "then one line listing the changes whenever the result differs from the last one.")
+	fmt.Fprintln(w, "Use --select and --where to watch only part of the result.")
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --interval <duration>  time between calls, e.g. 30s or 5m (default 1m)")
+	fmt.Fprintln(w, "  --count <n>            stop after n calls")
+	fmt.Fprintln(w, "  --on-change <command>  run a shell command after each change, with the change")
+	fmt.Fprintln(w, "                         line on its stdin")
+}
+
+// parseWatchFlags pulls the watch command's own flags out of args, which
+// may appear anywhere before or after the method name.
+func parseWatchFlags(args []string) (*watchOptions, []string, error) {
+	opts := &watchOptions{Interval: time.Minute}
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		needValue := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("flag %s needs a value", name)
+			}
+			i++
+			return args[i], nil
+		}
+
+		var err error
+		switch name {
+		case "--interval":
+			var v string
+			if v, err = needValue(); err == nil {
+				opts.Interval, err = time.ParseDuration(v)
+				if err == nil && opts.Interval <= 0 {
+					err = fmt.Errorf("--interval must be positive")
+				}
+			}
+		case "--count":
+			var v string
+			if v, err = needValue(); err == nil {
+				opts.Count, err = strconv.Atoi(v)
+				if err == nil && opts.Count < 1 {
+					err = fmt.Errorf("--count must be at least 1")
+				}
+			}
+		case "--on-change":
+			opts.OnChange, err = needValue()
+		default:
+			rest = append(rest, args[i])
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return opts, rest, nil
+}
+
+// runWatch implements the watch command and returns the process exit code.
+// Failed calls are reported and the watch goes on, except when the quota
+// is exhausted.
+func runWatch(opts *cliOptions, args []string) int {
+	if len(args) < 1 {
+		printWatchUsage(os.Stderr)
+		return exitUsage
+	}
+	if args[0] == "-h" || args[0] == "--help" {
+		printWatchUsage(os.Stdout)
+		return exitOK
+	}
+
+	wopts, rest, err := parseWatchFlags(args)
+	if err == nil && len(rest) == 0 {
+		err = errors.New("watch needs a method name")
+	}
+	if err != nil {
+		return opts.report(usageError(err, printWatchUsage))
+	}
+	info := c99.LookupMethod(rest[0])
+	if info == nil {
+		return opts.report(unknownMethodError(rest[0]))
+	}
+	parsed, err := parseMethodArgs(info, rest[1:])
+	if err != nil {
+		return opts.report(methodUsageError(info, err))
+	}
+	if parsed.Help {
+		printMethodUsage(os.Stdout, info)
+		return exitOK
+	}
+	callArgs, err := info.ResolveArgs(parsed.Positional, parsed.Named, opts.methodDefaults(info))
+	if err != nil {
+		return opts.report(methodUsageError(info, err))
+	}
+
+	client, err := opts.newClient()
+	if err != nil {
+		return opts.report(usageError(err, nil))
+	}
+	if opts.DryRun {
+		req, err := dryRun(client, info, callArgs)
+		if err == nil {
+			err = opts.printDryRun(req)
+		}
+		if err != nil {
+			return opts.report(err)
+		}
+		return exitOK
+	}
+	if opts.APIKey == "" {
+		return opts.report(errNoAPIKey)
+	}
+	// Every call must reach the API for changes to show.
+	client.Cache = nil
+
+	var prev interface{}
+	seen := false
+	for run := 1; ; run++ {
+		if run > 1 {
+			time.Sleep(wopts.Interval)
+		}
+		ev := watchEvent{Time: time.Now().UTC().Format(time.RFC3339), Run: run}
+		unchanged := false
+		result, err := info.Call(context.Background(), client, callArgs)
+		switch {
+		case err != nil && callExit(err) == exitQuota:
+			return opts.report(callError(info.Name, err))
+		case err != nil:
+			ev.Error, ev.ErrorCode = err.Error(), errorCodes[callExit(err)]
+		case !seen:
+			prev, seen = opts.postProcess(result), true
+			ev.Result = prev
+		default:
+			cur := opts.postProcess(result)
+			ev.Changes = c99.Diff(prev, cur)
+			prev = cur
+			unchanged = ev.Changes == nil
+		}
+
+		if !unchanged {
+			line, err := json.Marshal(ev)
+			if err == nil {
+				_, err = os.Stdout.Write(append(line, '\n'))
+			}
+			if err != nil {
+				return opts.report(fmt.Errorf("writing output: %v", err))
+			}
+			if ev.Changes != nil && wopts.OnChange != "" {
+				runWatchHook(wopts.OnChange, info, run, line)
+			}
+		}
+		if wopts.Count > 0 && run >= wopts.Count {
+			return exitOK
+		}
+	}
+}
+
+// runWatchHook runs the --on-change command with the change line on its
+// stdin. Its output goes to stderr so that stdout stays NDJSON, and a
+// failing hook does not stop the watch.
+func runWatchHook(command string, info *c99.MethodInfo, run int, line []byte) {
+	cmd := exec.Command("/bin/sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(append(line, '\n'))
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"C99_WATCH_METHOD="+info.Name,
+		"C99_WATCH_RUN="+strconv.Itoa(run),
+	)
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: --on-change command failed: %v\n", err)
+	}
+}
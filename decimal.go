@@ -0,0 +1,190 @@
+package c99
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact decimal number, used for amounts of money so that
+// balances and rates keep every digit the API sent. The zero value is 0.
+type Decimal struct {
+	// The value is unscaled / 10^scale; unscaled is never modified once
+	// set, so Decimals can be copied freely.
+	unscaled *big.Int
+	scale    int
+}
+
+// maxExponent bounds the exponent ParseDecimal accepts, so that an input
+// such as "1e999999999" cannot make it build an enormous number.
+const maxExponent = 1000
+
+// ParseDecimal parses a decimal such as "12", "-0.00012345" or "1.5e-3".
+// Exponents beyond ±1000 are rejected.
+func ParseDecimal(s string) (Decimal, error) {
+	mantissa, exp := strings.TrimSpace(s), 0
+	if i := strings.IndexAny(mantissa, "eE"); i >= 0 {
+		var err error
+		if exp, err = strconv.Atoi(mantissa[i+1:]); err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+		if exp > maxExponent || exp < -maxExponent {
+			return Decimal{}, fmt.Errorf("decimal %q: exponent out of range", s)
+		}
+		mantissa = mantissa[:i]
+	}
+	sign := ""
+	if strings.HasPrefix(mantissa, "-") || strings.HasPrefix(mantissa, "+") {
+		sign, mantissa = mantissa[:1], mantissa[1:]
+	}
+	whole, frac, _ := strings.Cut(mantissa, ".")
+	digits := whole + frac
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	n, _ := new(big.Int).SetString(sign+digits, 10)
+	return newDecimal(n, len(frac)-exp), nil
+}
+
+// newDecimal returns unscaled / 10^scale.
+func newDecimal(unscaled *big.Int, scale int) Decimal {
+	if scale < 0 {
+		unscaled = new(big.Int).Mul(unscaled, pow10(-scale))
+		scale = 0
+	}
+	return Decimal{unscaled: unscaled, scale: scale}
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func (d Decimal) int() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+// String formats d in plain decimal notation without trailing zeros.
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.int()).String()
+	sign := ""
+	if d.Sign() < 0 {
+		sign = "-"
+	}
+	if d.scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= d.scale {
+		digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+	}
+	whole, frac := digits[:len(digits)-d.scale], strings.TrimRight(digits[len(digits)-d.scale:], "0")
+	if frac == "" {
+		return sign + whole
+	}
+	return sign + whole + "." + frac
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of d.
+func (d Decimal) Sign() int { return d.int().Sign() }
+
+// IsZero reports whether d is 0.
+func (d Decimal) IsZero() bool { return d.Sign() == 0 }
+
+// Rat returns d as a fraction, for arithmetic beyond Add, Sub and Mul.
+func (d Decimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.int(), pow10(d.scale))
+}
+
+// Float64 returns the float64 nearest to d.
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+// Cmp compares d and e and returns -1, 0 or +1.
+func (d Decimal) Cmp(e Decimal) int {
+	a, b := align(d, e)
+	return a.Cmp(b)
+}
+
+// Add returns d + e.
+func (d Decimal) Add(e Decimal) Decimal {
+	a, b := align(d, e)
+	return newDecimal(new(big.Int).Add(a, b), max(d.scale, e.scale))
+}
+
+// Sub returns d - e.
+func (d Decimal) Sub(e Decimal) Decimal {
+	a, b := align(d, e)
+	return newDecimal(new(big.Int).Sub(a, b), max(d.scale, e.scale))
+}
+
+// Mul returns d * e.
+func (d Decimal) Mul(e Decimal) Decimal {
+	return newDecimal(new(big.Int).Mul(d.int(), e.int()), d.scale+e.scale)
+}
+
+// align returns the unscaled values of d and e brought to the same scale.
+func align(d, e Decimal) (*big.Int, *big.Int) {
+	a, b := d.int(), e.int()
+	switch {
+	case d.scale < e.scale:
+		a = new(big.Int).Mul(a, pow10(e.scale-d.scale))
+	case e.scale < d.scale:
+		b = new(big.Int).Mul(b, pow10(d.scale-e.scale))
+	}
+	return a, b
+}
+
+// shift returns d * 10^n as an integer, or false if that has a fraction.
+func (d Decimal) shift(n int) (*big.Int, bool) {
+	v := newDecimal(d.int(), d.scale-n)
+	q, r := new(big.Int).QuoRem(v.int(), pow10(v.scale), new(big.Int))
+	return q, r.Sign() == 0
+}
+
+// MarshalJSON writes d as a JSON string, so that decoders do not round it
+// to a float.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON reads a decimal sent as a JSON number or string. JSON null
+// reads as 0.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		*d = Decimal{}
+		return nil
+	}
+	if uq, err := strconv.Unquote(s); err == nil {
+		s = uq
+	}
+	v, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// asDecimal reads a decimal sent as a number or a string. Numbers lose
+// precision unless the response was decoded with json.Number.
+func asDecimal(v interface{}) (Decimal, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		d, err := ParseDecimal(v.String())
+		return d, err == nil
+	case float64:
+		d, err := ParseDecimal(strconv.FormatFloat(v, 'f', -1, 64))
+		return d, err == nil
+	case string:
+		d, err := ParseDecimal(v)
+		return d, err == nil
+	}
+	return Decimal{}, false
+}
@@ -0,0 +1,122 @@
+package c99
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustDecimal(t *testing.T, s string) Decimal {
+	t.Helper()
+	d, err := ParseDecimal(s)
+	if err != nil {
+		t.Fatalf("ParseDecimal(%q): %v", s, err)
+	}
+	return d
+}
+
+func TestParseDecimal(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"12", "12"},
+		{"-0.00012345", "-0.00012345"},
+		{"+1.50", "1.5"},
+		{"1.5e-3", "0.0015"},
+		{"1.5E3", "1500"},
+		{".5", "0.5"},
+		{"5.", "5"},
+		{" 0.000 ", "0"},
+		{"123456789012345678901234567890.000000000000000001", "123456789012345678901234567890.000000000000000001"},
+		{"1e1000", "1" + strings.Repeat("0", 1000)},
+		{"1e-1000", "0." + strings.Repeat("0", 999) + "1"},
+	} {
+		if got := mustDecimal(t, tc.in).String(); got != tc.want {
+			t.Errorf("ParseDecimal(%q) = %s, want %s", tc.in, got, tc.want)
+		}
+	}
+	for _, in := range []string{"", "-", ".", "abc", "1.2.3", "1e", "1e1.5", "NaN", "Inf", "0x10", "1,000"} {
+		if _, err := ParseDecimal(in); err == nil {
+			t.Errorf("ParseDecimal(%q) succeeded", in)
+		}
+	}
+}
+
+func TestParseDecimalExponentBound(t *testing.T) {
+	start := time.Now()
+	for _, in := range []string{"1e1001", "1e-1001", "1e999999999", "1e-999999999", "1e99999999999999999999"} {
+		if _, err := ParseDecimal(in); err == nil {
+			t.Errorf("ParseDecimal(%q) succeeded", in)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("rejecting huge exponents took %v", elapsed)
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	// The classic float64 failure: 0.1 + 0.2 != 0.3.
+	sum := mustDecimal(t, "0.1").Add(mustDecimal(t, "0.2"))
+	if sum.String() != "0.3" || sum.Cmp(mustDecimal(t, "0.3")) != 0 {
+		t.Errorf("0.1 + 0.2 = %s", sum)
+	}
+	for _, tc := range []struct {
+		a, b, add, sub, mul string
+		cmp                 int
+	}{
+		{"1.005", "0.005", "1.01", "1", "0.005025", 1},
+		{"-2", "0.5", "-1.5", "-2.5", "-1", -1},
+		{"19.99", "19.990", "39.98", "0", "399.6001", 0},
+		{"0.00000001", "21000000", "21000000.00000001", "-20999999.99999999", "0.21", -1},
+	} {
+		a, b := mustDecimal(t, tc.a), mustDecimal(t, tc.b)
+		if got := a.Add(b).String(); got != tc.add {
+			t.Errorf("%s + %s = %s, want %s", tc.a, tc.b, got, tc.add)
+		}
+		if got := a.Sub(b).String(); got != tc.sub {
+			t.Errorf("%s - %s = %s, want %s", tc.a, tc.b, got, tc.sub)
+		}
+		if got := a.Mul(b).String(); got != tc.mul {
+			t.Errorf("%s * %s = %s, want %s", tc.a, tc.b, got, tc.mul)
+		}
+		if got := a.Cmp(b); got != tc.cmp {
+			t.Errorf("Cmp(%s, %s) = %d, want %d", tc.a, tc.b, got, tc.cmp)
+		}
+	}
+
+	var zero Decimal
+	if !zero.IsZero() || zero.String() != "0" || zero.Add(mustDecimal(t, "1.5")).String() != "1.5" {
+		t.Errorf("zero Decimal misbehaves: %s", zero)
+	}
+	if r := mustDecimal(t, "0.25").Rat(); r.Cmp(big.NewRat(1, 4)) != 0 {
+		t.Errorf("Rat(0.25) = %s", r)
+	}
+	if f := mustDecimal(t, "-1.25").Float64(); f != -1.25 {
+		t.Errorf("Float64(-1.25) = %v", f)
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	var v struct {
+		A, B, C Decimal
+		D       *Decimal
+	}
+	v.C = mustDecimal(t, "7")
+	in := `{"A":0.123456789012345678901,"B":"-42.10","C":null,"D":null}`
+	if err := json.Unmarshal([]byte(in), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A.String() != "0.123456789012345678901" || v.B.String() != "-42.1" || !v.C.IsZero() || v.D != nil {
+		t.Errorf("decoded A=%s B=%s C=%s D=%v", v.A, v.B, v.C, v.D)
+	}
+	out, err := json.Marshal(v.A)
+	if err != nil || string(out) != `"0.123456789012345678901"` {
+		t.Errorf("Marshal = %s, %v", out, err)
+	}
+	for _, in := range []string{`"abc"`, `true`, `"1e5000"`} {
+		var d Decimal
+		if err := json.Unmarshal([]byte(in), &d); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded", in)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package c99
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"c99/internal/jsonvalue"
+)
+
+// Change is one difference between two decoded results. Path is in the
+// syntax accepted by ParsePath; Old is unset for additions and New for
+// removals.
+type Change struct {
+	Op   string      `json:"op"` // "add", "remove" or "replace"
+	Path string      `json:"path"`
+	Old  interface{} `json:"old"`
+	New  interface{} `json:"new"`
+}
+
+// MarshalJSON writes the old and new values the Op calls for, so that a
+// change to or from null keeps its null.
+func (c Change) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Op   string       `json:"op"`
+		Path string       `json:"path"`
+		Old  *interface{} `json:"old,omitempty"`
+		New  *interface{} `json:"new,omitempty"`
+	}{Op: c.Op, Path: c.Path}
+	if c.Op != "add" {
+		out.Old = &c.Old
+	}
+	if c.Op != "remove" {
+		out.New = &c.New
+	}
+	return json.Marshal(out)
+}
+
+// Diff returns the changes that turn old into new. Lists holding the same
+// elements in a different order compare equal, because the API returns
+// records such as DNS answers in no fixed order.
+func Diff(old, new interface{}) []Change {
+	var changes []Change
+	diffInto(&changes, "", old, new)
+	return changes
+}
+
+func diffInto(changes *[]Change, path string, a, b interface{}) {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		if b, ok := b.(map[string]interface{}); ok {
+			diffMaps(changes, path, a, b)
+			return
+		}
+	case []interface{}:
+		if b, ok := b.([]interface{}); ok {
+			diffLists(changes, path, a, b)
+			return
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Op: "replace", Path: rootPath(path), Old: a, New: b})
+	}
+}
+
+func diffMaps(changes *[]Change, path string, a, b map[string]interface{}) {
+	keys := jsonvalue.SortedKeys(a)
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		av, inA := a[k]
+		bv, inB := b[k]
+		p := path + pathKey(k)
+		switch {
+		case !inB:
+			*changes = append(*changes, Change{Op: "remove", Path: p, Old: av})
+		case !inA:
+			*changes = append(*changes, Change{Op: "add", Path: p, New: bv})
+		default:
+			diffInto(changes, p, av, bv)
+		}
+	}
+}
+
+func diffLists(changes *[]Change, path string, a, b []interface{}) {
+	if sameElements(a, b) {
+		return
+	}
+	for i := 0; i < len(a) || i < len(b); i++ {
+		p := rootPath(path) + "[" + strconv.Itoa(i) + "]"
+		switch {
+		case i >= len(b):
+			*changes = append(*changes, Change{Op: "remove", Path: p, Old: a[i]})
+		case i >= len(a):
+			*changes = append(*changes, Change{Op: "add", Path: p, New: b[i]})
+		default:
+			diffInto(changes, p, a[i], b[i])
+		}
+	}
+}
+
+// sameElements reports whether two lists hold the same elements, in any
+// order.
+func sameElements(a, b []interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	encode := func(list []interface{}) []string {
+		out := make([]string, len(list))
+		for i, v := range list {
+			data, _ := json.Marshal(v)
+			out[i] = string(data)
+		}
+		sort.Strings(out)
+		return out
+	}
+	return reflect.DeepEqual(encode(a), encode(b))
+}
+
+// pathKey returns the path step selecting key, quoting keys that
+// ParsePath would otherwise split.
+func pathKey(key string) string {
+	if key == "" || strings.ContainsAny(key, ".[]\" ") {
+		return "[" + strconv.Quote(key) + "]"
+	}
+	return "." + key
+}
+
+func rootPath(path string) string {
+	if path == "" {
+		return "."
+	}
+	return path
+}
@@ -0,0 +1,79 @@
+package c99
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	for _, tc := range []struct {
+		old, new string
+		want     []Change
+	}{
+		{`{"a":1,"b":[1,2]}`, `{"b":[1,2],"a":1}`, nil},
+		{`1`, `2`, []Change{{Op: "replace", Path: ".", Old: 1.0, New: 2.0}}},
+		{`{"a":1}`, `[1]`, []Change{{Op: "replace", Path: ".", Old: map[string]interface{}{"a": 1.0}, New: []interface{}{1.0}}}},
+		{`{"a":1,"b":2}`, `{"b":3,"c":4}`, []Change{
+			{Op: "remove", Path: ".a", Old: 1.0},
+			{Op: "replace", Path: ".b", Old: 2.0, New: 3.0},
+			{Op: "add", Path: ".c", New: 4.0},
+		}},
+		{`{"a":null}`, `{"a":"x"}`, []Change{{Op: "replace", Path: ".a", Old: nil, New: "x"}}},
+		{`{"a":"x"}`, `{"a":null}`, []Change{{Op: "replace", Path: ".a", Old: "x", New: nil}}},
+		{`{}`, `{"a":null}`, []Change{{Op: "add", Path: ".a", New: nil}}},
+		{`{"records":{"A":["1"]}}`, `{"records":{"A":["2"]}}`,
+			[]Change{{Op: "replace", Path: ".records.A[0]", Old: "1", New: "2"}}},
+		{`{"odd key":{"a.b":1}}`, `{"odd key":{"a.b":2}}`,
+			[]Change{{Op: "replace", Path: `["odd key"]["a.b"]`, Old: 1.0, New: 2.0}}},
+		{`{"":1}`, `{"":2}`, []Change{{Op: "replace", Path: `[""]`, Old: 1.0, New: 2.0}}},
+		// Lists compare regardless of order, including lists of objects.
+		{`{"ips":["192.0.2.1","192.0.2.2"]}`, `{"ips":["192.0.2.2","192.0.2.1"]}`, nil},
+		{`[{"ip":"a","n":1},{"ip":"b"}]`, `[{"ip":"b"},{"n":1,"ip":"a"}]`, nil},
+		{`[1,1,2]`, `[1,2,2]`, []Change{{Op: "replace", Path: ".[1]", Old: 1.0, New: 2.0}}},
+		// Lists that differ are compared by position.
+		{`{"p":[80,443]}`, `{"p":[80,443,8080]}`, []Change{{Op: "add", Path: ".p[2]", New: 8080.0}}},
+		{`{"p":[80,443]}`, `{"p":[22]}`, []Change{
+			{Op: "replace", Path: ".p[0]", Old: 80.0, New: 22.0},
+			{Op: "remove", Path: ".p[1]", Old: 443.0},
+		}},
+		{`[]`, `[{"a":1}]`, []Change{{Op: "add", Path: ".[0]", New: map[string]interface{}{"a": 1.0}}}},
+	} {
+		got := Diff(decodeJSON(t, tc.old), decodeJSON(t, tc.new))
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Diff(%s, %s) = %+v, want %+v", tc.old, tc.new, got, tc.want)
+		}
+		// Every path selects the changed value.
+		for _, c := range got {
+			if c.Op == "remove" {
+				continue
+			}
+			if v, err := Select(decodeJSON(t, tc.new), c.Path); err != nil || !reflect.DeepEqual(v, c.New) {
+				t.Errorf("Select(%s, %q) = %v, %v; want %v", tc.new, c.Path, v, err, c.New)
+			}
+		}
+	}
+}
+
+func TestChangeJSON(t *testing.T) {
+	for _, tc := range []struct {
+		c    Change
+		want string
+	}{
+		{Change{Op: "add", Path: ".a", New: nil}, `{"op":"add","path":".a","new":null}`},
+		{Change{Op: "remove", Path: ".a", Old: nil}, `{"op":"remove","path":".a","old":null}`},
+		{Change{Op: "replace", Path: ".a", Old: nil, New: 1}, `{"op":"replace","path":".a","old":null,"new":1}`},
+		{Change{Op: "replace", Path: ".a", Old: "x", New: nil}, `{"op":"replace","path":".a","old":"x","new":null}`},
+		{Change{Op: "replace", Path: ".", Old: 0, New: false}, `{"op":"replace","path":".","old":0,"new":false}`},
+	} {
+		got, err := json.Marshal(tc.c)
+		if err != nil || string(got) != tc.want {
+			t.Errorf("Marshal(%+v) = %s, %v; want %s", tc.c, got, err, tc.want)
+		}
+	}
+	// Changes in a struct field marshal the same way.
+	got, _ := json.Marshal(struct{ Changes []Change }{[]Change{{Op: "add", Path: ".a"}}})
+	if want := `{"Changes":[{"op":"add","path":".a","new":null}]}`; string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,38 @@
+package c99
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Do calls method and decodes the response straight into a T, typically a
+// struct of the caller's own for an endpoint without a typed variant.
+// method is a registered method name, in any of the spellings the CLI
+// accepts, or else a raw C99 endpoint name; params are the endpoint's
+// query parameters. Do goes through the same cache, retries and success
+// check as the other methods, so a response with success:false yields its
+// decoded T together with an *APIError. The response is decoded as the
+// API sent it, without Normalize, so T's field types must match the
+// API's; json.Number and interface{} fields accept either form.
+func Do[T any](ctx context.Context, c *C99, method string, params map[string]string, opts ...CallOption) (T, error) {
+	var out T
+	endpoint := method
+	if info := LookupMethod(method); info != nil {
+		endpoint = info.Endpoint
+	}
+	// fetch adds the key to the parameters; leave the caller's map alone.
+	query := make(map[string]string, len(params)+2)
+	for k, v := range params {
+		query[k] = v
+	}
+
+	body, err := c.fetch(ctx, endpoint, query, opts...)
+	if body == nil {
+		return out, err
+	}
+	if derr := json.Unmarshal(body, &out); derr != nil && err == nil {
+		err = fmt.Errorf("c99 %s: decoding response: %v", endpoint, derr)
+	}
+	return out, err
+}
@@ -0,0 +1,78 @@
+package c99
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestClient returns a client for a test server running handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *C99 {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return &C99{Key: "test-key", BaseURL: srv.URL + "/"}
+}
+
+func TestDo(t *testing.T) {
+	var gotPath string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		if r.URL.Query().Get("host") == "bad.example" {
+			w.Write([]byte(`{"success":false,"error":"Invalid host"}`))
+			return
+		}
+		w.Write([]byte(`{"success":true,"headers":{"Server":"nginx"},"count":"2"}`))
+	})
+
+	type headers struct {
+		Success bool              `json:"success"`
+		Headers map[string]string `json:"headers"`
+		Count   json.Number       `json:"count"`
+	}
+	for _, tc := range []struct{ method, path string }{
+		{"GetWebsiteHeaders", "/getheaders"},
+		{"get-website-headers", "/getheaders"},
+		{"getheaders", "/getheaders"},
+		{"notregistered", "/notregistered"},
+	} {
+		params := map[string]string{"host": "example.com"}
+		res, err := Do[headers](context.Background(), c, tc.method, params)
+		if err != nil {
+			t.Fatalf("Do(%q): %v", tc.method, err)
+		}
+		if gotPath != tc.path {
+			t.Errorf("Do(%q) requested %s, want %s", tc.method, gotPath, tc.path)
+		}
+		if !res.Success || res.Headers["Server"] != "nginx" || res.Count != "2" {
+			t.Errorf("Do(%q) = %+v", tc.method, res)
+		}
+		if len(params) != 1 {
+			t.Errorf("Do(%q) modified the caller's params: %v", tc.method, params)
+		}
+	}
+
+	res, err := Do[headers](context.Background(), c, "getheaders", map[string]string{"host": "bad.example"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "Invalid host" {
+		t.Fatalf("Do on a failed response: err = %v, want *APIError", err)
+	}
+	if res.Success {
+		t.Errorf("Do on a failed response: Success = true")
+	}
+}
+
+func TestDoDecodeError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success":true,"headers":"none"}`))
+	})
+	_, err := Do[struct {
+		Headers map[string]string `json:"headers"`
+	}](context.Background(), c, "getheaders", nil)
+	if err == nil {
+		t.Fatal("Do: no error decoding a string into a map")
+	}
+}
@@ -0,0 +1,247 @@
+package c99
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Digits after the point of the main unit of each chain.
+const (
+	satoshiDigits = 8
+	weiDigits     = 18
+)
+
+// SatoshiToBTC converts an amount in satoshi to bitcoin.
+func SatoshiToBTC(satoshi *big.Int) Decimal {
+	return newDecimal(new(big.Int).Set(satoshi), satoshiDigits)
+}
+
+// BTCToSatoshi converts an amount in bitcoin to satoshi. It fails if the
+// amount is finer than one satoshi.
+func BTCToSatoshi(btc Decimal) (*big.Int, error) {
+	n, ok := btc.shift(satoshiDigits)
+	if !ok {
+		return nil, fmt.Errorf("%s BTC is not a whole number of satoshi", btc)
+	}
+	return n, nil
+}
+
+// WeiToETH converts an amount in wei to ether.
+func WeiToETH(wei *big.Int) Decimal {
+	return newDecimal(new(big.Int).Set(wei), weiDigits)
+}
+
+// ETHToWei converts an amount in ether to wei. It fails if the amount is
+// finer than one wei.
+func ETHToWei(eth Decimal) (*big.Int, error) {
+	n, ok := eth.shift(weiDigits)
+	if !ok {
+		return nil, fmt.Errorf("%s ETH is not a whole number of wei", eth)
+	}
+	return n, nil
+}
+
+// formatAmount formats an amount given as any Go integer or float type, a
+// Decimal, *big.Int, *big.Float, json.Number or numeric string.
+func formatAmount(amount interface{}) (string, error) {
+	switch v := amount.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v), nil
+	case float32:
+		return formatFloat(float64(v), 32)
+	case float64:
+		return formatFloat(v, 64)
+	case Decimal:
+		return v.String(), nil
+	case *big.Int:
+		return v.String(), nil
+	case *big.Float:
+		if v.IsInf() {
+			return "", fmt.Errorf("invalid amount %v", v)
+		}
+		return v.Text('f', -1), nil
+	case json.Number:
+		return formatAmount(v.String())
+	case string:
+		d, err := ParseDecimal(v)
+		if err != nil {
+			return "", fmt.Errorf("invalid amount %q", v)
+		}
+		return d.String(), nil
+	}
+	return "", fmt.Errorf("unsupported amount type %T", amount)
+}
+
+func formatFloat(f float64, bits int) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("invalid amount %v", f)
+	}
+	return strconv.FormatFloat(f, 'f', -1, bits), nil
+}
+
+// CurrencyResult is the typed result of CurrencyConverter.
+type CurrencyResult struct {
+	Result
+	Amount Decimal `json:"amount"`
+	From   string  `json:"from"`
+	To     string  `json:"to"`
+	// Converted is Amount expressed in To.
+	Converted Decimal `json:"converted"`
+}
+
+// NewCurrencyResult decodes a CurrencyConverter response. Responses that
+// do not echo the request leave Amount, From and To unset.
+func NewCurrencyResult(raw map[string]interface{}) *CurrencyResult {
+	r := &CurrencyResult{Result: newResult(raw)}
+	v, _ := lookup(raw, "result", "converted", "value")
+	r.Converted, _ = asDecimal(v)
+	r.Amount, _ = asDecimal(raw["amount"])
+	r.From = strings.ToUpper(asString(raw["from"]))
+	r.To = strings.ToUpper(asString(raw["to"]))
+	return r
+}
+
+// Rate returns the exchange rate implied by the conversion, or nil if
+// Amount is zero.
+func (r *CurrencyResult) Rate() *big.Rat {
+	if r.Amount.IsZero() {
+		return nil
+	}
+	return new(big.Rat).Quo(r.Converted.Rat(), r.Amount.Rat())
+}
+
+// RatesResult is the typed result of CurrencyRates.
+type RatesResult struct {
+	Result
+	Source string `json:"source"`
+	// Rates maps currency codes to the value of one unit of Source.
+	Rates map[string]Decimal `json:"rates"`
+}
+
+// NewRatesResult decodes a CurrencyRates response.
+func NewRatesResult(raw map[string]interface{}) *RatesResult {
+	r := &RatesResult{Result: newResult(raw), Rates: make(map[string]Decimal)}
+	r.Source = strings.ToUpper(asString(raw["source"]))
+	v, _ := lookup(raw, "rates", "result")
+	rates, _ := v.(map[string]interface{})
+	for code, rate := range rates {
+		// Rates come either as plain numbers or as {"rate": ...}.
+		if m, ok := rate.(map[string]interface{}); ok {
+			rate, _ = lookup(m, "rate", "value")
+		}
+		if d, ok := asDecimal(rate); ok {
+			r.Rates[strings.ToUpper(code)] = d
+		}
+	}
+	return r
+}
+
+// BitcoinBalanceResult is the typed result of BitcoinBalance.
+type BitcoinBalanceResult struct {
+	Result
+	// Balance is in BTC.
+	Balance Decimal `json:"balance"`
+	// Satoshi is nil if the response gave neither the balance in satoshi
+	// nor a balance in BTC that converts exactly.
+	Satoshi *big.Int `json:"satoshi"`
+}
+
+// NewBitcoinBalanceResult decodes a BitcoinBalance response.
+func NewBitcoinBalanceResult(raw map[string]interface{}) *BitcoinBalanceResult {
+	r := &BitcoinBalanceResult{Result: newResult(raw)}
+	r.Balance, r.Satoshi = balance(raw, BTCToSatoshi, SatoshiToBTC, "balance_satoshi", "satoshi")
+	return r
+}
+
+// EthereumBalanceResult is the typed result of EthereumBalance.
+type EthereumBalanceResult struct {
+	Result
+	// Balance is in ETH.
+	Balance Decimal `json:"balance"`
+	// Wei is nil if the response gave neither the balance in wei nor a
+	// balance in ETH that converts exactly.
+	Wei *big.Int `json:"wei"`
+}
+
+// NewEthereumBalanceResult decodes an EthereumBalance response.
+func NewEthereumBalanceResult(raw map[string]interface{}) *EthereumBalanceResult {
+	r := &EthereumBalanceResult{Result: newResult(raw)}
+	r.Balance, r.Wei = balance(raw, ETHToWei, WeiToETH, "balance_wei", "wei")
+	return r
+}
+
+// balance reads a wallet balance in the main unit and in the smallest
+// unit, found under one of unitKeys, deriving whichever is missing.
+func balance(raw map[string]interface{}, toUnit func(Decimal) (*big.Int, error),
+	fromUnit func(*big.Int) Decimal, unitKeys ...string) (Decimal, *big.Int) {
+	if v, ok := lookup(raw, unitKeys...); ok {
+		if d, ok := asDecimal(v); ok {
+			if n, ok := d.shift(0); ok {
+				return fromUnit(n), n
+			}
+		}
+	}
+	v, _ := lookup(raw, "balance", "result")
+	d, ok := asDecimal(v)
+	if !ok {
+		return Decimal{}, nil
+	}
+	n, err := toUnit(d)
+	if err != nil {
+		return d, nil
+	}
+	return d, n
+}
+
+// CurrencyConverterTyped is CurrencyConverter with a typed result. amount
+// may be any Go integer or float type, a Decimal, *big.Int, *big.Float,
+// json.Number or numeric string.
+func (c *C99) CurrencyConverterTyped(ctx context.Context, amount interface{}, fromCurrency, toCurrency string, opts ...CallOption) (*CurrencyResult, error) {
+	s, err := formatAmount(amount)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := c.request(ctx, "currency", map[string]string{"amount": s, "from": fromCurrency, "to": toCurrency}, true, opts...)
+	return typed(raw, err, func(raw map[string]interface{}) *CurrencyResult {
+		r := NewCurrencyResult(raw)
+		if _, ok := raw["amount"]; !ok {
+			r.Amount, _ = ParseDecimal(s)
+		}
+		if r.From == "" {
+			r.From = strings.ToUpper(fromCurrency)
+		}
+		if r.To == "" {
+			r.To = strings.ToUpper(toCurrency)
+		}
+		return r
+	})
+}
+
+// CurrencyRatesTyped is CurrencyRates with a typed result.
+func (c *C99) CurrencyRatesTyped(ctx context.Context, source string, opts ...CallOption) (*RatesResult, error) {
+	raw, err := c.request(ctx, "currencyrates", map[string]string{"source": source}, true, opts...)
+	return typed(raw, err, func(raw map[string]interface{}) *RatesResult {
+		r := NewRatesResult(raw)
+		if r.Source == "" {
+			r.Source = strings.ToUpper(source)
+		}
+		return r
+	})
+}
+
+// BitcoinBalanceTyped is BitcoinBalance with a typed result.
+func (c *C99) BitcoinBalanceTyped(ctx context.Context, address string, opts ...CallOption) (*BitcoinBalanceResult, error) {
+	raw, err := c.request(ctx, "bitcoinbalance", map[string]string{"address": address}, true, opts...)
+	return typed(raw, err, NewBitcoinBalanceResult)
+}
+
+// EthereumBalanceTyped is EthereumBalance with a typed result.
+func (c *C99) EthereumBalanceTyped(ctx context.Context, address string, opts ...CallOption) (*EthereumBalanceResult, error) {
+	raw, err := c.request(ctx, "ethereumbalance", map[string]string{"address": address}, true, opts...)
+	return typed(raw, err, NewEthereumBalanceResult)
+}
@@ -0,0 +1,151 @@
+package c99
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"testing"
+)
+
+func TestSatoshiConversions(t *testing.T) {
+	for _, tc := range []struct {
+		btc     string
+		satoshi string
+	}{
+		{"1", "100000000"},
+		{"0.00000001", "1"},
+		{"21000000", "2100000000000000"},
+		{"-0.5", "-50000000"},
+		{"0", "0"},
+	} {
+		n, err := BTCToSatoshi(mustDecimal(t, tc.btc))
+		if err != nil || n.String() != tc.satoshi {
+			t.Errorf("BTCToSatoshi(%s) = %v, %v; want %s", tc.btc, n, err, tc.satoshi)
+		}
+		want, _ := new(big.Int).SetString(tc.satoshi, 10)
+		if got := SatoshiToBTC(want).String(); got != tc.btc {
+			t.Errorf("SatoshiToBTC(%s) = %s, want %s", tc.satoshi, got, tc.btc)
+		}
+	}
+	if _, err := BTCToSatoshi(mustDecimal(t, "0.000000001")); err == nil {
+		t.Error("BTCToSatoshi accepted a fraction of a satoshi")
+	}
+}
+
+func TestWeiConversions(t *testing.T) {
+	wei, _ := new(big.Int).SetString("1234567890123456789012", 10)
+	eth := WeiToETH(wei)
+	if eth.String() != "1234.567890123456789012" {
+		t.Errorf("WeiToETH = %s", eth)
+	}
+	back, err := ETHToWei(eth)
+	if err != nil || back.Cmp(wei) != 0 {
+		t.Errorf("ETHToWei(WeiToETH(x)) = %v, %v; want %v", back, err, wei)
+	}
+	// The result does not share wei's storage.
+	wei.SetInt64(0)
+	if eth.IsZero() {
+		t.Error("WeiToETH aliases its argument")
+	}
+	if _, err := ETHToWei(mustDecimal(t, "1e-19")); err == nil {
+		t.Error("ETHToWei accepted a fraction of a wei")
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	for _, tc := range []struct {
+		in   interface{}
+		want string
+	}{
+		{10, "10"},
+		{int64(-3), "-3"},
+		{uint8(7), "7"},
+		{19.99, "19.99"},
+		{float32(0.5), "0.5"},
+		{mustDecimal(t, "0.10"), "0.1"},
+		{big.NewInt(5), "5"},
+		{big.NewFloat(2.25), "2.25"},
+		{json.Number("1e2"), "100"},
+		{" 3.50 ", "3.5"},
+	} {
+		if got, err := formatAmount(tc.in); err != nil || got != tc.want {
+			t.Errorf("formatAmount(%#v) = %q, %v; want %q", tc.in, got, err, tc.want)
+		}
+	}
+	for _, in := range []interface{}{"ten", nil, []int{1}} {
+		if _, err := formatAmount(in); err == nil {
+			t.Errorf("formatAmount(%#v) succeeded", in)
+		}
+	}
+}
+
+func TestNewBalanceResults(t *testing.T) {
+	for _, tc := range []struct {
+		raw, balance, satoshi string
+	}{
+		{`{"success":true,"balance":"0.12345678"}`, "0.12345678", "12345678"},
+		{`{"success":true,"balance_satoshi":"150000000"}`, "1.5", "150000000"},
+		{`{"success":true,"result":"0.000000001"}`, "0.000000001", "<nil>"},
+		{`{"success":true}`, "0", "<nil>"},
+	} {
+		r := NewBitcoinBalanceResult(decodeExact(t, tc.raw))
+		if r.Balance.String() != tc.balance || r.Satoshi.String() != tc.satoshi {
+			t.Errorf("%s: Balance = %s, Satoshi = %s; want %s, %s", tc.raw, r.Balance, r.Satoshi, tc.balance, tc.satoshi)
+		}
+	}
+	r := NewEthereumBalanceResult(decodeExact(t, `{"success":true,"balance":1.000000000000000001}`))
+	if r.Balance.String() != "1.000000000000000001" || r.Wei.String() != "1000000000000000001" {
+		t.Errorf("Ethereum: Balance = %s, Wei = %s", r.Balance, r.Wei)
+	}
+}
+
+func TestNewCurrencyResults(t *testing.T) {
+	conv := NewCurrencyResult(decodeExact(t, `{"success":true,"amount":"10","from":"usd","to":"eur","result":9.2}`))
+	if conv.Amount.String() != "10" || conv.From != "USD" || conv.To != "EUR" || conv.Converted.String() != "9.2" {
+		t.Errorf("CurrencyResult = %+v", conv)
+	}
+	if rate := conv.Rate(); rate == nil || rate.Cmp(big.NewRat(92, 100)) != 0 {
+		t.Errorf("Rate() = %v, want 0.92", rate)
+	}
+	if rate := NewCurrencyResult(decodeExact(t, `{"success":true,"result":1}`)).Rate(); rate != nil {
+		t.Errorf("Rate() without an amount = %v, want nil", rate)
+	}
+
+	rates := NewRatesResult(decodeExact(t, `{"success":true,"source":"usd",
+		"rates":{"eur":0.92,"GBP":{"rate":"0.79"},"XXX":"n/a"}}`))
+	if rates.Source != "USD" || len(rates.Rates) != 2 ||
+		rates.Rates["EUR"].String() != "0.92" || rates.Rates["GBP"].String() != "0.79" {
+		t.Errorf("RatesResult = %+v", rates)
+	}
+}
+
+// decodeExact decodes a response keeping numbers as json.Number, as the
+// finance methods do.
+func decodeExact(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	raw, err := decodeResponse([]byte(s), true)
+	if err != nil {
+		t.Fatalf("bad fixture %s: %v", s, err)
+	}
+	return raw
+}
+
+func TestFinanceTypedKeepsDigits(t *testing.T) {
+	var query map[string][]string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Write([]byte(`{"success":true,"result":0.30000000000000000004}`))
+	})
+	res, err := c.CurrencyConverterTyped(context.Background(), 0.1, "usd", "eur")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := query["amount"]; len(got) != 1 || got[0] != "0.1" {
+		t.Errorf("sent amount %q, want 0.1", got)
+	}
+	if res.Converted.String() != "0.30000000000000000004" || res.Amount.String() != "0.1" ||
+		res.From != "USD" || res.To != "EUR" {
+		t.Errorf("CurrencyConverterTyped = %+v", res)
+	}
+}
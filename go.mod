@@ -0,0 +1,3 @@
+module c99
+
+go 1.24
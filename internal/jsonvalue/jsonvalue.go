@@ -0,0 +1,58 @@
+// Package jsonvalue holds helpers for values decoded from JSON into
+// interface{}, shared by the client and the command-line tool.
+package jsonvalue
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// SortedKeys returns the keys of m in order.
+func SortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Scalar renders a decoded JSON scalar as plain text.
+func Scalar(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// PrimaryList returns the longest list found among the top-level fields of
+// result, along with its key.
+func PrimaryList(result interface{}) (string, []interface{}) {
+	switch v := result.(type) {
+	case []interface{}:
+		return "value", v
+	case map[string]interface{}:
+		var bestKey string
+		var best []interface{}
+		for _, k := range SortedKeys(v) {
+			if list, ok := v[k].([]interface{}); ok && len(list) > len(best) {
+				bestKey, best = k, list
+			}
+		}
+		return bestKey, best
+	}
+	return "", nil
+}
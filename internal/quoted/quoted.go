@@ -0,0 +1,43 @@
+// Package quoted splits text that may contain quoted strings.
+package quoted
+
+import "strings"
+
+// Split splits s on sep, ignoring separators inside double
+// quoted strings.
+func Split(s, sep string) []string {
+	return split(s, sep, false)
+}
+
+// SplitLiteral is Split that also ignores separators inside single quoted
+// strings, in which backslashes do not escape, as in TOML and YAML. A
+// single quote only opens a string at the start of an element, so that
+// apostrophes in bare words are left alone.
+func SplitLiteral(s, sep string) []string {
+	return split(s, sep, true)
+}
+
+func split(s, sep string, single bool) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == '\\' && quote == '"' {
+				i++
+			} else if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"':
+			quote = '"'
+		case s[i] == '\'' && single && strings.TrimSpace(s[start:i]) == "":
+			quote = '\''
+		case strings.HasPrefix(s[i:], sep):
+			parts = append(parts, s[start:i])
+			start = i + len(sep)
+			i += len(sep) - 1
+		}
+	}
+	return append(parts, s[start:])
+}
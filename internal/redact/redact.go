@@ -0,0 +1,15 @@
+// Package redact keeps API keys out of output.
+package redact
+
+import "net/url"
+
+// URL returns u as a string with the API key hidden.
+func URL(u *url.URL) string {
+	r := *u
+	q := r.Query()
+	if q.Has("key") {
+		q.Set("key", "REDACTED")
+	}
+	r.RawQuery = q.Encode()
+	return r.String()
+}
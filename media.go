@@ -0,0 +1,319 @@
+package c99
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// VideoResult is the typed result of YouTubeVideoDetails.
+type VideoResult struct {
+	Result
+	Title       string        `json:"title"`
+	Description string        `json:"description,omitempty"`
+	Channel     string        `json:"channel,omitempty"`
+	Views       int           `json:"views"`
+	Likes       int           `json:"likes,omitempty"`
+	Duration    time.Duration `json:"-"`
+	Thumbnail   *url.URL      `json:"-"`
+}
+
+// NewVideoResult decodes a YouTubeVideoDetails response.
+func NewVideoResult(raw map[string]interface{}) *VideoResult {
+	r := &VideoResult{Result: newResult(raw)}
+	str := func(keys ...string) string {
+		v, _ := lookup(raw, keys...)
+		return asString(v)
+	}
+	r.Title = str("title")
+	r.Description = str("description")
+	r.Channel = str("channel", "channel_title", "channeltitle", "author", "uploader")
+	v, _ := lookup(raw, "views", "view_count", "viewcount")
+	r.Views, _ = asCount(v)
+	v, _ = lookup(raw, "likes", "like_count", "likecount")
+	r.Likes, _ = asCount(v)
+	v, _ = lookup(raw, "duration", "length")
+	r.Duration, _ = asDuration(v)
+	v, _ = lookup(raw, "thumbnail", "thumbnail_url", "thumb")
+	r.Thumbnail = asURL(v)
+	return r
+}
+
+// MarshalJSON writes the duration in seconds and the thumbnail as a string.
+func (r *VideoResult) MarshalJSON() ([]byte, error) {
+	type plain VideoResult
+	return json.Marshal(struct {
+		*plain
+		Duration  float64 `json:"duration"`
+		Thumbnail string  `json:"thumbnail,omitempty"`
+	}{(*plain)(r), r.Duration.Seconds(), urlString(r.Thumbnail)})
+}
+
+// MP3Result is the typed result of YouTubeToMP3.
+type MP3Result struct {
+	Result
+	Title    string        `json:"title,omitempty"`
+	Duration time.Duration `json:"-"`
+	// Download is where the MP3 can be fetched.
+	Download *url.URL `json:"-"`
+}
+
+// NewMP3Result decodes a YouTubeToMP3 response.
+func NewMP3Result(raw map[string]interface{}) *MP3Result {
+	r := &MP3Result{Result: newResult(raw)}
+	v, _ := lookup(raw, "title")
+	r.Title = asString(v)
+	v, _ = lookup(raw, "duration", "length")
+	r.Duration, _ = asDuration(v)
+	v, _ = lookup(raw, "download", "download_url", "url", "link", "result")
+	r.Download = asURL(v)
+	return r
+}
+
+// MarshalJSON writes the duration in seconds and the link as a string.
+func (r *MP3Result) MarshalJSON() ([]byte, error) {
+	type plain MP3Result
+	return json.Marshal(struct {
+		*plain
+		Duration float64 `json:"duration,omitempty"`
+		Download string  `json:"download,omitempty"`
+	}{(*plain)(r), r.Duration.Seconds(), urlString(r.Download)})
+}
+
+// ImageResult is the typed result of ScreenshotTool and QRCodeGenerator,
+// which both answer with a link to the image they made.
+type ImageResult struct {
+	Result
+	Image *url.URL `json:"-"`
+}
+
+// NewImageResult decodes a ScreenshotTool or QRCodeGenerator response.
+func NewImageResult(raw map[string]interface{}) *ImageResult {
+	v, _ := lookup(raw, "url", "image", "screenshot", "qr", "result")
+	return &ImageResult{Result: newResult(raw), Image: asURL(v)}
+}
+
+// MarshalJSON writes the image link as a string.
+func (r *ImageResult) MarshalJSON() ([]byte, error) {
+	type plain ImageResult
+	return json.Marshal(struct {
+		*plain
+		Image string `json:"image,omitempty"`
+	}{(*plain)(r), urlString(r.Image)})
+}
+
+// PictureResult is the typed result of ImageReverse.
+type PictureResult struct {
+	Result
+	// Description says what the picture shows.
+	Description string   `json:"description"`
+	Labels      []string `json:"labels,omitempty"`
+}
+
+// NewPictureResult decodes an ImageReverse response.
+func NewPictureResult(raw map[string]interface{}) *PictureResult {
+	r := &PictureResult{Result: newResult(raw)}
+	v, _ := lookup(raw, "result", "description")
+	r.Description = asString(v)
+	v, _ = lookup(raw, "labels", "tags")
+	r.Labels = asList(v, ",\n")
+	return r
+}
+
+// TextResult is the typed result of TextParser.
+type TextResult struct {
+	Result
+	Text string `json:"text"`
+}
+
+// NewTextResult decodes a TextParser response.
+func NewTextResult(raw map[string]interface{}) *TextResult {
+	v, _ := lookup(raw, "result", "text")
+	return &TextResult{Result: newResult(raw), Text: asString(v)}
+}
+
+// GIFResult is the typed result of GIFFinder.
+type GIFResult struct {
+	Result
+	GIFs []*url.URL `json:"-"`
+}
+
+// NewGIFResult decodes a GIFFinder response.
+func NewGIFResult(raw map[string]interface{}) *GIFResult {
+	r := &GIFResult{Result: newResult(raw)}
+	v, _ := lookup(raw, "gifs", "images", "result", "url")
+	list, ok := v.([]interface{})
+	if !ok {
+		list = []interface{}{v}
+	}
+	for _, e := range list {
+		if m, ok := e.(map[string]interface{}); ok {
+			e, _ = lookup(m, "url", "gif", "link")
+		}
+		if u := asURL(e); u != nil {
+			r.GIFs = append(r.GIFs, u)
+		}
+	}
+	return r
+}
+
+// MarshalJSON writes the links as strings.
+func (r *GIFResult) MarshalJSON() ([]byte, error) {
+	type plain GIFResult
+	gifs := make([]string, len(r.GIFs))
+	for i, u := range r.GIFs {
+		gifs[i] = urlString(u)
+	}
+	return json.Marshal(struct {
+		*plain
+		GIFs []string `json:"gifs"`
+	}{(*plain)(r), gifs})
+}
+
+// DefinitionResult is the typed result of Dictionary.
+type DefinitionResult struct {
+	Result
+	Word        string   `json:"word"`
+	Definitions []string `json:"definitions"`
+}
+
+// NewDefinitionResult decodes a Dictionary response.
+func NewDefinitionResult(raw map[string]interface{}) *DefinitionResult {
+	r := &DefinitionResult{Result: newResult(raw)}
+	v, _ := lookup(raw, "word")
+	r.Word = asString(v)
+	v, _ = lookup(raw, "definitions", "definition", "result")
+	if list, ok := v.([]interface{}); ok {
+		for _, e := range list {
+			if m, ok := e.(map[string]interface{}); ok {
+				e, _ = lookup(m, "definition", "meaning", "text")
+			}
+			if s := asString(e); s != "" {
+				r.Definitions = append(r.Definitions, s)
+			}
+		}
+	} else {
+		r.Definitions = asList(v, "\n")
+	}
+	return r
+}
+
+// SynonymResult is the typed result of SynonymFinder.
+type SynonymResult struct {
+	Result
+	Word     string   `json:"word"`
+	Synonyms []string `json:"synonyms"`
+}
+
+// NewSynonymResult decodes a SynonymFinder response.
+func NewSynonymResult(raw map[string]interface{}) *SynonymResult {
+	r := &SynonymResult{Result: newResult(raw)}
+	v, _ := lookup(raw, "word")
+	r.Word = asString(v)
+	v, _ = lookup(raw, "synonyms", "result")
+	r.Synonyms = asList(v, ",;\n")
+	return r
+}
+
+// TranslationResult is the typed result of Translator.
+type TranslationResult struct {
+	Result
+	Text string `json:"text"`
+	// From is the detected source language, when the API reports it.
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+// NewTranslationResult decodes a Translator response.
+func NewTranslationResult(raw map[string]interface{}) *TranslationResult {
+	r := &TranslationResult{Result: newResult(raw)}
+	str := func(keys ...string) string {
+		v, _ := lookup(raw, keys...)
+		return asString(v)
+	}
+	r.Text = str("result", "translation", "translated", "text")
+	r.From = str("from", "source", "detected_language", "fromlanguage")
+	r.To = str("to", "target", "tolanguage")
+	return r
+}
+
+// YouTubeVideoDetailsTyped is YouTubeVideoDetails with a typed result.
+func (c *C99) YouTubeVideoDetailsTyped(ctx context.Context, videoid string, opts ...CallOption) (*VideoResult, error) {
+	raw, err := c.YouTubeVideoDetails(ctx, videoid, opts...)
+	return typed(raw, err, NewVideoResult)
+}
+
+// YouTubeToMP3Typed is YouTubeToMP3 with a typed result.
+func (c *C99) YouTubeToMP3Typed(ctx context.Context, videoid string, opts ...CallOption) (*MP3Result, error) {
+	raw, err := c.YouTubeToMP3(ctx, videoid, opts...)
+	return typed(raw, err, NewMP3Result)
+}
+
+// ScreenshotToolTyped is ScreenshotTool with a typed result.
+func (c *C99) ScreenshotToolTyped(ctx context.Context, pageURL string, opts ...CallOption) (*ImageResult, error) {
+	raw, err := c.ScreenshotTool(ctx, pageURL, opts...)
+	return typed(raw, err, NewImageResult)
+}
+
+// QRCodeGeneratorTyped is QRCodeGenerator with a typed result; size is
+// in pixels.
+func (c *C99) QRCodeGeneratorTyped(ctx context.Context, str string, size int, opts ...CallOption) (*ImageResult, error) {
+	raw, err := c.QRCodeGenerator(ctx, str, strconv.Itoa(size), opts...)
+	return typed(raw, err, NewImageResult)
+}
+
+// ImageReverseTyped is ImageReverse with a typed result.
+func (c *C99) ImageReverseTyped(ctx context.Context, imageURL string, opts ...CallOption) (*PictureResult, error) {
+	raw, err := c.ImageReverse(ctx, imageURL, opts...)
+	return typed(raw, err, NewPictureResult)
+}
+
+// TextParserTyped is TextParser with a typed result.
+func (c *C99) TextParserTyped(ctx context.Context, pageURL string, opts ...CallOption) (*TextResult, error) {
+	raw, err := c.TextParser(ctx, pageURL, opts...)
+	return typed(raw, err, NewTextResult)
+}
+
+// GIFFinderTyped is GIFFinder with a typed result.
+func (c *C99) GIFFinderTyped(ctx context.Context, keyword string, opts ...CallOption) (*GIFResult, error) {
+	raw, err := c.GIFFinder(ctx, keyword, opts...)
+	return typed(raw, err, NewGIFResult)
+}
+
+// DictionaryTyped is Dictionary with a typed result.
+func (c *C99) DictionaryTyped(ctx context.Context, word string, opts ...CallOption) (*DefinitionResult, error) {
+	raw, err := c.Dictionary(ctx, word, opts...)
+	return typed(raw, err, func(raw map[string]interface{}) *DefinitionResult {
+		r := NewDefinitionResult(raw)
+		if r.Word == "" {
+			r.Word = word
+		}
+		return r
+	})
+}
+
+// SynonymFinderTyped is SynonymFinder with a typed result.
+func (c *C99) SynonymFinderTyped(ctx context.Context, word string, opts ...CallOption) (*SynonymResult, error) {
+	raw, err := c.SynonymFinder(ctx, word, opts...)
+	return typed(raw, err, func(raw map[string]interface{}) *SynonymResult {
+		r := NewSynonymResult(raw)
+		if r.Word == "" {
+			r.Word = word
+		}
+		return r
+	})
+}
+
+// TranslatorTyped is Translator with a typed result.
+func (c *C99) TranslatorTyped(ctx context.Context, text, tolanguage string, opts ...CallOption) (*TranslationResult, error) {
+	raw, err := c.Translator(ctx, text, tolanguage, opts...)
+	return typed(raw, err, func(raw map[string]interface{}) *TranslationResult {
+		r := NewTranslationResult(raw)
+		if r.To == "" {
+			r.To = tolanguage
+		}
+		return r
+	})
+}
@@ -0,0 +1,177 @@
+package c99
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewVideoResult(t *testing.T) {
+	r := NewVideoResult(decodeRaw(t, `{"success":true,"title":"Never Gonna Give You Up",
+		"channel_title":"Rick Astley","view_count":"1,234,567 views","likes":"1.2M",
+		"duration":"PT3M33S","thumbnail":"//i.ytimg.com/vi/dQw4w9WgXcQ/hqdefault.jpg"}`))
+	if r.Title != "Never Gonna Give You Up" || r.Channel != "Rick Astley" ||
+		r.Views != 1234567 || r.Likes != 1200000 || r.Duration != 213*time.Second {
+		t.Errorf("got %+v", r)
+	}
+	if got := urlString(r.Thumbnail); got != "https://i.ytimg.com/vi/dQw4w9WgXcQ/hqdefault.jpg" {
+		t.Errorf("Thumbnail = %q", got)
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	json.Unmarshal(b, &got)
+	if got["duration"] != float64(213) || got["thumbnail"] != "https://i.ytimg.com/vi/dQw4w9WgXcQ/hqdefault.jpg" ||
+		got["views"] != float64(1234567) || got["success"] != true {
+		t.Errorf("MarshalJSON = %s", b)
+	}
+}
+
+func TestAsDuration(t *testing.T) {
+	for _, tc := range []struct {
+		v    interface{}
+		want time.Duration
+		ok   bool
+	}{
+		{float64(90), 90 * time.Second, true},
+		{"212.5", 212500 * time.Millisecond, true},
+		{"3:21", 201 * time.Second, true},
+		{"1:02:03", time.Hour + 2*time.Minute + 3*time.Second, true},
+		{"PT1H2M", time.Hour + 2*time.Minute, true},
+		{"P1DT1S", 24*time.Hour + time.Second, true},
+		{"P1M", 0, false},
+		{"PT3", 0, false},
+		{"3m21s", 201 * time.Second, true},
+		{"3:x", 0, false},
+		{true, 0, false},
+	} {
+		if got, ok := asDuration(tc.v); got != tc.want || ok != tc.ok {
+			t.Errorf("asDuration(%#v) = %v, %v; want %v, %v", tc.v, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestAsCount(t *testing.T) {
+	for _, tc := range []struct {
+		v    interface{}
+		want int
+		ok   bool
+	}{
+		{float64(12), 12, true},
+		{"1234567", 1234567, true},
+		{"1,234 views", 1234, true},
+		{"2.5k", 2500, true},
+		{"1.2B", 1200000000, true},
+		{json.Number("7"), 7, true},
+		{"", 0, false},
+		{"lots", 0, false},
+	} {
+		if got, ok := asCount(tc.v); got != tc.want || ok != tc.ok {
+			t.Errorf("asCount(%#v) = %v, %v; want %v, %v", tc.v, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestNewMP3Result(t *testing.T) {
+	r := NewMP3Result(decodeRaw(t, `{"success":true,"title":"Song","length":"4:05",
+		"download_url":"https://cdn.example.com/a.mp3"}`))
+	if r.Title != "Song" || r.Duration != 245*time.Second || urlString(r.Download) != "https://cdn.example.com/a.mp3" {
+		t.Errorf("got %+v", r)
+	}
+	// A relative or missing link is left nil rather than guessed.
+	if r := NewMP3Result(decodeRaw(t, `{"success":true,"result":"/a.mp3"}`)); r.Download != nil {
+		t.Errorf("Download = %v, want nil", r.Download)
+	}
+}
+
+func TestNewImageResult(t *testing.T) {
+	for _, raw := range []string{
+		`{"success":true,"url":"https://api.c99.nl/screenshots/a.png"}`,
+		`{"success":true,"qr":"https://api.c99.nl/screenshots/a.png"}`,
+		`{"success":true,"result":"https://api.c99.nl/screenshots/a.png"}`,
+	} {
+		r := NewImageResult(decodeRaw(t, raw))
+		b, _ := json.Marshal(r)
+		if urlString(r.Image) != "https://api.c99.nl/screenshots/a.png" ||
+			string(b) != `{"success":true,"image":"https://api.c99.nl/screenshots/a.png"}` {
+			t.Errorf("%s: got %s", raw, b)
+		}
+	}
+}
+
+func TestNewGIFResult(t *testing.T) {
+	for _, tc := range []struct {
+		raw  string
+		want []string
+	}{
+		{`{"success":true,"gifs":["https://a.example/1.gif",{"url":"https://a.example/2.gif"},"nope"]}`,
+			[]string{"https://a.example/1.gif", "https://a.example/2.gif"}},
+		{`{"success":true,"result":"https://a.example/1.gif"}`, []string{"https://a.example/1.gif"}},
+		{`{"success":true}`, nil},
+	} {
+		r := NewGIFResult(decodeRaw(t, tc.raw))
+		var got []string
+		for _, u := range r.GIFs {
+			got = append(got, urlString(u))
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: GIFs = %q, want %q", tc.raw, got, tc.want)
+		}
+	}
+	// No links marshal as an empty list, not null.
+	if b, _ := json.Marshal(NewGIFResult(decodeRaw(t, `{"success":true}`))); string(b) != `{"success":true,"gifs":[]}` {
+		t.Errorf("MarshalJSON = %s", b)
+	}
+}
+
+func TestNewTextResults(t *testing.T) {
+	pic := NewPictureResult(decodeRaw(t, `{"success":true,"result":"a cat","tags":"cat, animal\npet"}`))
+	if pic.Description != "a cat" || !reflect.DeepEqual(pic.Labels, []string{"cat", "animal", "pet"}) {
+		t.Errorf("PictureResult = %+v", pic)
+	}
+	if got := NewTextResult(decodeRaw(t, `{"success":true,"text":"Hello"}`)).Text; got != "Hello" {
+		t.Errorf("Text = %q", got)
+	}
+
+	def := NewDefinitionResult(decodeRaw(t, `{"success":true,"word":"run",
+		"definitions":[{"definition":"to move fast"},"to operate",{"other":1}]}`))
+	if def.Word != "run" || !reflect.DeepEqual(def.Definitions, []string{"to move fast", "to operate"}) {
+		t.Errorf("DefinitionResult = %+v", def)
+	}
+	def = NewDefinitionResult(decodeRaw(t, `{"success":true,"result":"to move fast, on foot\nto operate"}`))
+	if !reflect.DeepEqual(def.Definitions, []string{"to move fast, on foot", "to operate"}) {
+		t.Errorf("Definitions = %q", def.Definitions)
+	}
+
+	syn := NewSynonymResult(decodeRaw(t, `{"success":true,"result":"quick, fast;rapid\nswift"}`))
+	if !reflect.DeepEqual(syn.Synonyms, []string{"quick", "fast", "rapid", "swift"}) {
+		t.Errorf("Synonyms = %q", syn.Synonyms)
+	}
+
+	tr := NewTranslationResult(decodeRaw(t, `{"success":true,"result":"Hallo","detected_language":"en"}`))
+	if tr.Text != "Hallo" || tr.From != "en" || tr.To != "" {
+		t.Errorf("TranslationResult = %+v", tr)
+	}
+}
+
+func TestTypedMediaMethods(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success":true,"result":"x"}`))
+	})
+	ctx := context.Background()
+	// The word and target language asked for fill in what the API omits.
+	if r, err := c.DictionaryTyped(ctx, "run"); err != nil || r.Word != "run" {
+		t.Errorf("DictionaryTyped = %+v, %v", r, err)
+	}
+	if r, err := c.SynonymFinderTyped(ctx, "fast"); err != nil || r.Word != "fast" {
+		t.Errorf("SynonymFinderTyped = %+v, %v", r, err)
+	}
+	if r, err := c.TranslatorTyped(ctx, "Hello", "nl"); err != nil || r.To != "nl" || r.Text != "x" {
+		t.Errorf("TranslatorTyped = %+v, %v", r, err)
+	}
+}
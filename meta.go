@@ -0,0 +1,112 @@
+package c99
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Meta records how a response was obtained, for auditing.
+type Meta struct {
+	Endpoint string
+	// Params are the query parameters sent, with the API key redacted.
+	Params map[string]string
+	// Status is the HTTP status of the last attempt; zero for a cache hit
+	// or when no response arrived.
+	Status int
+	// Latency covers the whole call, including retries and their waits.
+	Latency time.Duration
+	// Attempts is how many times the request was sent; zero for a cache
+	// hit.
+	Attempts int
+	CacheHit bool
+	// CacheAge is how long ago a cached response was stored.
+	CacheAge time.Duration
+	// KeyFingerprint identifies the API key without revealing it; see
+	// keyFingerprint.
+	KeyFingerprint string
+	// Time is when the call started, in UTC.
+	Time time.Time
+}
+
+// MarshalJSON writes durations in milliseconds and the time in RFC 3339.
+func (m *Meta) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Endpoint       string            `json:"endpoint"`
+		Params         map[string]string `json:"params"`
+		Status         int               `json:"http_status,omitempty"`
+		LatencyMS      float64           `json:"latency_ms"`
+		Attempts       int               `json:"attempts"`
+		CacheHit       bool              `json:"cache_hit"`
+		CacheAgeMS     float64           `json:"cache_age_ms,omitempty"`
+		KeyFingerprint string            `json:"key_fingerprint,omitempty"`
+		Time           string            `json:"timestamp"`
+	}{m.Endpoint, m.Params, m.Status, milliseconds(m.Latency), m.Attempts, m.CacheHit,
+		milliseconds(m.CacheAge), m.KeyFingerprint, m.Time.Format(time.RFC3339Nano)})
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// Response is a decoded result together with how it was obtained.
+type Response struct {
+	Result map[string]interface{} `json:"result"`
+	Meta   *Meta                  `json:"meta"`
+}
+
+// keyFingerprint returns "sha256:" and the first 16 hex digits of the
+// key's SHA-256 hash, enough to tell keys apart in logs without exposing
+// them. It is empty when there is no key.
+func keyFingerprint(key string) string {
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// redactParams copies params with the API key replaced.
+func redactParams(params map[string]string) map[string]string {
+	r := make(map[string]string, len(params))
+	for k, v := range params {
+		r[k] = v
+	}
+	if _, ok := r["key"]; ok {
+		r["key"] = "REDACTED"
+	}
+	return r
+}
+
+// Call calls the registry method named method with positional arguments,
+// filling in defaults from the registry, and returns the result with its
+// metadata. The Response is returned even when the call fails, so that
+// failures can be audited too; its Meta is nil if no request was made, as
+// in a dry run.
+func (c *C99) Call(ctx context.Context, method string, args []string, opts ...CallOption) (*Response, error) {
+	info := LookupMethod(method)
+	if info == nil {
+		return nil, fmt.Errorf("unknown method %q", method)
+	}
+	callArgs, err := info.ResolveArgs(args, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.CallMethod(ctx, info, callArgs, opts...)
+}
+
+// CallMethod is Call for a method already looked up, with arguments
+// already resolved by ResolveArgs.
+func (c *C99) CallMethod(ctx context.Context, info *MethodInfo, args []string, opts ...CallOption) (*Response, error) {
+	mc := *c
+	mc.meta = new(Meta)
+	result, err := info.Call(ctx, &mc, args, opts...)
+	resp := &Response{Result: result}
+	if mc.meta.Endpoint != "" {
+		resp.Meta = mc.meta
+	}
+	return resp, err
+}
@@ -0,0 +1,237 @@
+package c99
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// normalizeRule lists the fields of one endpoint's response that are
+// converted to canonical JSON types, as paths in the syntax of ParsePath.
+type normalizeRule struct {
+	// Bools are converted from "true", "yes", "1" and the like; other
+	// strings, such as "valid" or "clean", are left alone.
+	Bools []string
+	// Numbers are converted from numeric strings.
+	Numbers []string
+	// Lists become [] when null or missing, and a one-element list when
+	// they hold a single value. They are converted before Bools and
+	// Numbers, so that paths such as ".open_ports[]" reach every element.
+	Lists []string
+	// SplitLists are Lists whose single value may be a string of elements
+	// separated by commas, such as "80,443".
+	SplitLists []string
+}
+
+// normalizeRules are the per-endpoint rules applied by Normalize. Every
+// response also gets lower-case top-level keys and a boolean "success".
+var normalizeRules = map[string]normalizeRule{
+	"subdomainfinder":       {Lists: []string{".subdomains"}, Bools: []string{".subdomains[].cloudflare"}},
+	"portscanner":           {SplitLists: []string{".open_ports"}, Numbers: []string{".open_ports[]"}},
+	"ping":                  {Numbers: []string{".time", ".latency"}},
+	"dnschecker":            {Lists: []string{".records[]"}},
+	"alexarank":             {Numbers: []string{".result", ".rank"}},
+	"upordown":              {Numbers: []string{".responsetime", ".code"}},
+	"reputationchecker":     {Numbers: []string{".score", ".risk_score"}},
+	"emailvalidator":        {Bools: []string{".result", ".valid", ".disposable"}},
+	"disposablemailchecker": {Bools: []string{".result", ".disposable"}},
+	"ipvalidator":           {Bools: []string{".result", ".valid"}},
+	"torchecker":            {Bools: []string{".result", ".tor"}},
+	"proxydetector":         {Bools: []string{".result", ".proxy"}},
+	"phonelookup":           {Bools: []string{".valid", ".is_valid"}},
+	"youtubedetails":        {Numbers: []string{".views", ".likes", ".dislikes", ".comments"}},
+	"bitcoinbalance":        {Numbers: []string{".balance", ".balance_satoshi"}},
+	"ethereumbalance":       {Numbers: []string{".balance", ".balance_wei"}},
+	"currency":              {Numbers: []string{".result", ".amount"}},
+	"currencyrates":         {Numbers: []string{".rates[]", ".rates[].rate"}},
+	"weather":               {Numbers: []string{".temperature", ".humidity", ".wind_speed"}},
+	"randomnumber":          {Numbers: []string{".result"}},
+}
+
+// methodNormalizeRules are the rules of methods that share an endpoint
+// with another method but answer differently: CheckPort calls the
+// portscanner endpoint with a port, and reports on that port alone.
+var methodNormalizeRules = map[string]normalizeRule{
+	"CheckPort": {Bools: []string{".open", ".result"}},
+}
+
+// Normalize returns a copy of a decoded response with the inconsistencies
+// of the API smoothed over: top-level keys are lower case, "success" is a
+// boolean, and the fields named by the rule for name have canonical
+// types. Name is the endpoint called or, for methods such as CheckPort
+// that share an endpoint, the method name. Numbers converted from
+// strings are float64, or json.Number if the response was decoded with
+// json.Number. Rules are not applied to failed responses, whose fields
+// are not filled in.
+func Normalize(name string, raw map[string]interface{}) map[string]interface{} {
+	return normalize(normalizeRuleFor(name), raw, containsNumber(raw))
+}
+
+// requestNormalizeRule returns the rule for a request to endpoint with
+// params.
+func requestNormalizeRule(endpoint string, params map[string]string) normalizeRule {
+	if endpoint == "portscanner" && params["port"] != "" {
+		return methodNormalizeRules["CheckPort"]
+	}
+	return normalizeRules[endpoint]
+}
+
+// normalizeRuleFor returns the rule for an endpoint or method name.
+func normalizeRuleFor(name string) normalizeRule {
+	info := LookupMethod(name)
+	if info == nil {
+		return normalizeRules[name]
+	}
+	if rule, ok := methodNormalizeRules[info.Name]; ok {
+		return rule
+	}
+	return normalizeRules[info.Endpoint]
+}
+
+func normalize(rule normalizeRule, raw map[string]interface{}, exact bool) map[string]interface{} {
+	if raw == nil {
+		return nil
+	}
+	result := make(map[string]interface{}, len(raw))
+	for k, v := range raw {
+		lk := strings.ToLower(k)
+		if _, taken := raw[lk]; taken && lk != k {
+			// A key spelt both ways keeps its lower-case value.
+			continue
+		}
+		result[lk] = cloneValue(v)
+	}
+	if v, ok := result["success"]; ok {
+		result["success"] = normalizeBool(v)
+	}
+	if success, ok := result["success"].(bool); ok && !success {
+		return result
+	}
+
+	for _, expr := range rule.Lists {
+		mustParsePath(expr).apply(result, normalizeList)
+	}
+	for _, expr := range rule.SplitLists {
+		mustParsePath(expr).apply(result, splitList)
+	}
+	for _, expr := range rule.Bools {
+		mustParsePath(expr).apply(result, normalizeBool)
+	}
+	for _, expr := range rule.Numbers {
+		mustParsePath(expr).apply(result, func(v interface{}) interface{} {
+			return normalizeNumber(v, exact)
+		})
+	}
+	return result
+}
+
+func mustParsePath(expr string) *Path {
+	p, err := ParsePath(expr)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func normalizeBool(v interface{}) interface{} {
+	switch v.(type) {
+	case string, float64, json.Number:
+		if b, ok := asBool(v); ok {
+			return b
+		}
+	}
+	return v
+}
+
+var thousands = regexp.MustCompile(`^-?\d{1,3}(,\d{3})+(\.\d+)?$`)
+
+func normalizeNumber(v interface{}, exact bool) interface{} {
+	s, ok := v.(string)
+	if !ok {
+		return v
+	}
+	s = strings.TrimSpace(s)
+	if thousands.MatchString(s) {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+	// ParseDecimal rejects the "NaN" and "Inf" ParseFloat accepts.
+	if _, err := ParseDecimal(s); err != nil {
+		return v
+	}
+	if exact {
+		return json.Number(s)
+	}
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+func normalizeList(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil:
+		return []interface{}{}
+	case []interface{}, map[string]interface{}:
+		return v
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return []interface{}{}
+		}
+	}
+	return []interface{}{v}
+}
+
+// splitList is normalizeList for lists that may arrive as one string of
+// comma-separated elements.
+func splitList(v interface{}) interface{} {
+	s, ok := v.(string)
+	if !ok {
+		return normalizeList(v)
+	}
+	list := []interface{}{}
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			list = append(list, e)
+		}
+	}
+	return list
+}
+
+// containsNumber reports whether v holds a json.Number anywhere.
+func containsNumber(v interface{}) bool {
+	switch v := v.(type) {
+	case json.Number:
+		return true
+	case map[string]interface{}:
+		for _, e := range v {
+			if containsNumber(e) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, e := range v {
+			if containsNumber(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cloneValue deep-copies a decoded JSON value.
+func cloneValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = cloneValue(e)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, e := range v {
+			l[i] = cloneValue(e)
+		}
+		return l
+	}
+	return v
+}
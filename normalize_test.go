@@ -0,0 +1,75 @@
+package c99
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		raw, want string
+	}{
+		{"portscanner", `{"Success":"true","open_ports":"80"}`, `{"success":true,"open_ports":[80]}`},
+		{"portscanner", `{"success":true,"open_ports":"80, 443"}`, `{"success":true,"open_ports":[80,443]}`},
+		{"portscanner", `{"success":true,"open_ports":["22","x"]}`, `{"success":true,"open_ports":[22,"x"]}`},
+		{"portscanner", `{"success":true,"open_ports":null}`, `{"success":true,"open_ports":[]}`},
+		{"portscanner", `{"success":true}`, `{"success":true,"open_ports":[]}`},
+		{"PortScanner", `{"success":true,"open_ports":""}`, `{"success":true,"open_ports":[]}`},
+		// CheckPort shares the endpoint but has no list of ports.
+		{"CheckPort", `{"success":true,"result":"open"}`, `{"success":true,"result":"open"}`},
+		{"checkport", `{"success":true,"open":"1"}`, `{"success":true,"open":true}`},
+		{"subdomainfinder", `{"success":true,"subdomains":{"subdomain":"a.example.com","cloudflare":"false"}}`,
+			`{"success":true,"subdomains":{"subdomain":"a.example.com","cloudflare":"false"}}`},
+		{"subdomainfinder", `{"success":true,"subdomains":[{"subdomain":"a.example.com","cloudflare":"yes"}]}`,
+			`{"success":true,"subdomains":[{"subdomain":"a.example.com","cloudflare":true}]}`},
+		{"GetSubDomains", `{"success":true}`, `{"success":true,"subdomains":[]}`},
+		// A single record is wrapped, and commas are kept inside it.
+		{"dnschecker", `{"success":true,"records":{"TXT":"v=spf1 a, mx","A":null}}`,
+			`{"success":true,"records":{"TXT":["v=spf1 a, mx"],"A":[]}}`},
+		{"emailvalidator", `{"success":true,"result":"valid","disposable":"no"}`,
+			`{"success":true,"result":"valid","disposable":false}`},
+		{"youtubedetails", `{"success":true,"views":"1,234,567","likes":"12 likes"}`,
+			`{"success":true,"views":1234567,"likes":"12 likes"}`},
+		{"randomnumber", `{"success":true,"result":"NaN"}`, `{"success":true,"result":"NaN"}`},
+		{"currencyrates", `{"success":true,"rates":{"EUR":"0.9","USD":{"rate":"1"}}}`,
+			`{"success":true,"rates":{"EUR":0.9,"USD":{"rate":1}}}`},
+		// Failed responses only get their keys and success fixed.
+		{"portscanner", `{"SUCCESS":"0","Error":"Invalid host"}`, `{"success":false,"error":"Invalid host"}`},
+		// A key spelt both ways keeps its lower-case value.
+		{"unknown", `{"success":true,"Result":"A","result":"a"}`, `{"success":true,"result":"a"}`},
+	} {
+		got := Normalize(tc.name, decodeRaw(t, tc.raw))
+		if want := decodeRaw(t, tc.want); !reflect.DeepEqual(got, want) {
+			b, _ := json.Marshal(got)
+			t.Errorf("Normalize(%q, %s) = %s, want %s", tc.name, tc.raw, b, tc.want)
+		}
+	}
+}
+
+func TestNormalizeExact(t *testing.T) {
+	// A response decoded with json.Number keeps the digits of converted
+	// strings too.
+	raw := decodeExact(t, `{"success":true,"balance":"0.10000000000000000001","balance_wei":100000000000000000001}`)
+	got := Normalize("ethereumbalance", raw)
+	if got["balance"] != json.Number("0.10000000000000000001") || got["balance_wei"] != json.Number("100000000000000000001") {
+		t.Errorf("got %#v", got)
+	}
+}
+
+func TestNormalizeCopies(t *testing.T) {
+	raw := decodeRaw(t, `{"success":"true","open_ports":["80"]}`)
+	Normalize("portscanner", raw)
+	if raw["success"] != "true" || raw["open_ports"].([]interface{})[0] != "80" {
+		t.Errorf("Normalize modified its argument: %v", raw)
+	}
+}
+
+func TestRequestNormalizeRule(t *testing.T) {
+	scan := requestNormalizeRule("portscanner", map[string]string{"host": "192.0.2.1"})
+	check := requestNormalizeRule("portscanner", map[string]string{"host": "192.0.2.1", "port": "443"})
+	if !reflect.DeepEqual(scan, normalizeRules["portscanner"]) || !reflect.DeepEqual(check, methodNormalizeRules["CheckPort"]) {
+		t.Errorf("got %+v and %+v", scan, check)
+	}
+}
@@ -0,0 +1,47 @@
+package c99
+
+import "time"
+
+// CallOption adjusts a single call, overriding the client's settings for
+// that call only.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	timeout time.Duration
+	noCache bool
+	params  map[string]string
+}
+
+func newCallOptions(opts []CallOption) *callOptions {
+	o := new(callOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithTimeout bounds the whole call, retries included, by d. It replaces
+// the timeout of the client's HTTPClient, so it can be longer as well as
+// shorter: slow endpoints such as PortScanner and ScreenshotTool can be
+// given minutes while quick ones keep a short default.
+func WithTimeout(d time.Duration) CallOption {
+	return func(o *callOptions) { o.timeout = d }
+}
+
+// NoCache sends the request even if the cache holds a response for it.
+// A successful response still replaces the cached one.
+func NoCache() CallOption {
+	return func(o *callOptions) { o.noCache = true }
+}
+
+// ExtraParam adds a query parameter the method does not expose, or
+// overrides one it sets. The API key and the json flag cannot be
+// overridden.
+func ExtraParam(name, value string) CallOption {
+	return func(o *callOptions) {
+		if o.params == nil {
+			o.params = make(map[string]string)
+		}
+		o.params[name] = value
+	}
+}
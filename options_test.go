@@ -0,0 +1,123 @@
+package c99
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithTimeout(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(300 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		w.Write([]byte(`{"success":true,"open_ports":[80]}`))
+	})
+	c.HTTPClient = &http.Client{Timeout: 50 * time.Millisecond}
+	ctx := context.Background()
+
+	if _, err := c.PortScanner(ctx, "192.0.2.1"); err == nil {
+		t.Fatal("PortScanner: no error from a response slower than the client timeout")
+	}
+	// A longer per-call timeout replaces the client's.
+	if _, err := c.PortScanner(ctx, "192.0.2.1", WithTimeout(5*time.Second)); err != nil {
+		t.Fatalf("PortScanner with WithTimeout(5s): %v", err)
+	}
+	// A shorter one applies too.
+	c.HTTPClient = nil
+	_, err := c.PortScanner(ctx, "192.0.2.1", WithTimeout(50*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("PortScanner with WithTimeout(50ms): err = %v, want deadline exceeded", err)
+	}
+}
+
+func TestWithTimeoutCoversRetries(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	c.Retries = 10
+	start := time.Now()
+	_, err := c.IPValidator(context.Background(), "192.0.2.1", WithTimeout(200*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("call took %v despite a 200ms timeout", elapsed)
+	}
+	if n := calls.Load(); n < 1 || n > 2 {
+		t.Errorf("made %d attempts within 200ms, want 1 or 2", n)
+	}
+}
+
+func TestExtraParam(t *testing.T) {
+	var query map[string][]string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Write([]byte(`{"success":true,"open_ports":[]}`))
+	})
+	_, err := c.PortScanner(context.Background(), "192.0.2.1",
+		ExtraParam("ports", "1-1024"),
+		ExtraParam("host", "192.0.2.2"),
+		ExtraParam("key", "stolen"),
+		ExtraParam("json", "false"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"ports": "1-1024",    // added
+		"host":  "192.0.2.2", // overrides the method's own parameter
+		"key":   "test-key",  // cannot be overridden
+		"json":  "true",
+	} {
+		if got := query[name]; len(got) != 1 || got[0] != want {
+			t.Errorf("query %s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestNoCache(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"success":true,"open_ports":[80]}`))
+	})
+	c.Cache = &FileCache{Dir: t.TempDir()}
+	ctx := context.Background()
+
+	for _, opts := range [][]CallOption{nil, nil, {NoCache()}, nil} {
+		if _, err := c.PortScanner(ctx, "192.0.2.1", opts...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("sent %d requests, want 2: the first and the NoCache one", n)
+	}
+}
+
+func TestOptionsReachTypedAndCall(t *testing.T) {
+	var ports []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		ports = append(ports, r.URL.Query().Get("ports"))
+		w.Write([]byte(`{"success":true,"open_ports":[80]}`))
+	})
+	ctx := context.Background()
+	if _, err := c.PortScannerTyped(ctx, "192.0.2.1", ExtraParam("ports", "a")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Call(ctx, "PortScanner", []string{"192.0.2.1"}, ExtraParam("ports", "b")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Do[map[string]interface{}](ctx, c, "portscanner", nil, ExtraParam("ports", "c")); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(ports); got != 3 || ports[0] != "a" || ports[1] != "b" || ports[2] != "c" {
+		t.Errorf("ports sent = %q, want [a b c]", ports)
+	}
+}
@@ -0,0 +1,248 @@
+package c99
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Subdomain is one entry found by GetSubDomains.
+type Subdomain struct {
+	Name string `json:"subdomain"`
+	// IP is empty when the subdomain did not resolve.
+	IP         string `json:"ip,omitempty"`
+	Cloudflare bool   `json:"cloudflare"`
+}
+
+// SubdomainsResult is the typed result of GetSubDomains.
+type SubdomainsResult struct {
+	Result
+	Subdomains []Subdomain `json:"subdomains"`
+}
+
+// NewSubdomainsResult decodes a GetSubDomains response.
+func NewSubdomainsResult(raw map[string]interface{}) *SubdomainsResult {
+	r := &SubdomainsResult{Result: newResult(raw)}
+	list, _ := raw["subdomains"].([]interface{})
+	for _, e := range list {
+		switch e := e.(type) {
+		case map[string]interface{}:
+			sub := Subdomain{Name: asString(e["subdomain"]), IP: asString(e["ip"])}
+			if sub.IP == "none" {
+				sub.IP = ""
+			}
+			sub.Cloudflare, _ = asBool(e["cloudflare"])
+			r.Subdomains = append(r.Subdomains, sub)
+		case string:
+			r.Subdomains = append(r.Subdomains, Subdomain{Name: e})
+		}
+	}
+	return r
+}
+
+// PortScanResult is the typed result of PortScanner.
+type PortScanResult struct {
+	Result
+	OpenPorts []int `json:"open_ports"`
+}
+
+// NewPortScanResult decodes a PortScanner response.
+func NewPortScanResult(raw map[string]interface{}) *PortScanResult {
+	return &PortScanResult{Result: newResult(raw), OpenPorts: openPorts(raw)}
+}
+
+// openPorts reads the open ports of a port scan, sorted.
+func openPorts(raw map[string]interface{}) []int {
+	v, _ := lookup(raw, "open_ports", "ports", "result")
+	var ports []int
+	if list, ok := v.([]interface{}); ok {
+		for _, e := range list {
+			if n, ok := asInt(e); ok {
+				ports = append(ports, n)
+			}
+		}
+	} else {
+		for _, s := range asStringList(v) {
+			if n, err := strconv.Atoi(s); err == nil {
+				ports = append(ports, n)
+			}
+		}
+	}
+	sort.Ints(ports)
+	return ports
+}
+
+// CheckPortResult is the typed result of CheckPort.
+type CheckPortResult struct {
+	Result
+	Port int  `json:"port"`
+	Open bool `json:"open"`
+}
+
+// NewCheckPortResult decodes a CheckPort response for port.
+func NewCheckPortResult(raw map[string]interface{}, port int) *CheckPortResult {
+	r := &CheckPortResult{Result: newResult(raw), Port: port}
+	if v, ok := lookup(raw, "open", "status", "result"); ok {
+		if s, isString := v.(string); isString && strings.EqualFold(s, "open") {
+			r.Open = true
+		} else {
+			r.Open, _ = asBool(v)
+		}
+		return r
+	}
+	for _, p := range openPorts(raw) {
+		if p == port {
+			r.Open = true
+		}
+	}
+	return r
+}
+
+// PingResult is the typed result of Ping.
+type PingResult struct {
+	Result
+	// Output is the ping command's output.
+	Output string `json:"output"`
+}
+
+// NewPingResult decodes a Ping response.
+func NewPingResult(raw map[string]interface{}) *PingResult {
+	v, _ := lookup(raw, "result", "output")
+	return &PingResult{Result: newResult(raw), Output: asString(v)}
+}
+
+// HostnameResult is the typed result of HostnameResolver.
+type HostnameResult struct {
+	Result
+	Hostname string `json:"hostname"`
+}
+
+// NewHostnameResult decodes a HostnameResolver response.
+func NewHostnameResult(raw map[string]interface{}) *HostnameResult {
+	v, _ := lookup(raw, "hostname", "result")
+	return &HostnameResult{Result: newResult(raw), Hostname: asString(v)}
+}
+
+// HostToIPResult is the typed result of HostToIP.
+type HostToIPResult struct {
+	Result
+	IPs []string `json:"ips"`
+}
+
+// NewHostToIPResult decodes a HostToIP response.
+func NewHostToIPResult(raw map[string]interface{}) *HostToIPResult {
+	v, _ := lookup(raw, "ips", "ip", "result")
+	return &HostToIPResult{Result: newResult(raw), IPs: asStringList(v)}
+}
+
+// IPToDomainsResult is the typed result of IPToDomains.
+type IPToDomainsResult struct {
+	Result
+	Domains []string `json:"domains"`
+}
+
+// NewIPToDomainsResult decodes an IPToDomains response.
+func NewIPToDomainsResult(raw map[string]interface{}) *IPToDomainsResult {
+	v, _ := lookup(raw, "domains", "data", "result")
+	return &IPToDomainsResult{Result: newResult(raw), Domains: asStringList(v)}
+}
+
+// DNSRecordsResult is the typed result of DNSChecker.
+type DNSRecordsResult struct {
+	Result
+	// Records maps record types such as "A" or "MX" to their values.
+	Records map[string][]string `json:"records"`
+}
+
+// NewDNSRecordsResult decodes a DNSChecker response.
+func NewDNSRecordsResult(raw map[string]interface{}) *DNSRecordsResult {
+	r := &DNSRecordsResult{Result: newResult(raw), Records: make(map[string][]string)}
+	v, _ := lookup(raw, "records", "result")
+	records, _ := v.(map[string]interface{})
+	for typ, values := range records {
+		r.Records[strings.ToUpper(typ)] = asStringList(values)
+	}
+	return r
+}
+
+// FirewallResult is the typed result of FirewallResolver.
+type FirewallResult struct {
+	Result
+	// Firewall names the detected firewall, or is empty.
+	Firewall string `json:"firewall,omitempty"`
+	Detected bool   `json:"detected"`
+}
+
+// NewFirewallResult decodes a FirewallResolver response.
+func NewFirewallResult(raw map[string]interface{}) *FirewallResult {
+	r := &FirewallResult{Result: newResult(raw)}
+	v, _ := lookup(raw, "firewall", "result")
+	if detected, ok := asBool(v); ok {
+		r.Detected = detected
+		return r
+	}
+	name := asString(v)
+	switch strings.ToLower(name) {
+	case "", "none", "no firewall detected", "not detected":
+	default:
+		r.Firewall, r.Detected = name, true
+	}
+	return r
+}
+
+// GetSubDomainsTyped is GetSubDomains with a typed result.
+func (c *C99) GetSubDomainsTyped(ctx context.Context, domain string, opts ...CallOption) (*SubdomainsResult, error) {
+	raw, err := c.GetSubDomains(ctx, domain, opts...)
+	return typed(raw, err, NewSubdomainsResult)
+}
+
+// PortScannerTyped is PortScanner with a typed result.
+func (c *C99) PortScannerTyped(ctx context.Context, ip string, opts ...CallOption) (*PortScanResult, error) {
+	raw, err := c.PortScanner(ctx, ip, opts...)
+	return typed(raw, err, NewPortScanResult)
+}
+
+// CheckPortTyped is CheckPort with a typed result.
+func (c *C99) CheckPortTyped(ctx context.Context, host string, port int, opts ...CallOption) (*CheckPortResult, error) {
+	raw, err := c.CheckPort(ctx, host, strconv.Itoa(port), opts...)
+	return typed(raw, err, func(raw map[string]interface{}) *CheckPortResult {
+		return NewCheckPortResult(raw, port)
+	})
+}
+
+// PingTyped is Ping with a typed result.
+func (c *C99) PingTyped(ctx context.Context, ip string, opts ...CallOption) (*PingResult, error) {
+	raw, err := c.Ping(ctx, ip, opts...)
+	return typed(raw, err, NewPingResult)
+}
+
+// HostnameResolverTyped is HostnameResolver with a typed result.
+func (c *C99) HostnameResolverTyped(ctx context.Context, ip string, opts ...CallOption) (*HostnameResult, error) {
+	raw, err := c.HostnameResolver(ctx, ip, opts...)
+	return typed(raw, err, NewHostnameResult)
+}
+
+// HostToIPTyped is HostToIP with a typed result.
+func (c *C99) HostToIPTyped(ctx context.Context, host string, opts ...CallOption) (*HostToIPResult, error) {
+	raw, err := c.HostToIP(ctx, host, opts...)
+	return typed(raw, err, NewHostToIPResult)
+}
+
+// IPToDomainsTyped is IPToDomains with a typed result.
+func (c *C99) IPToDomainsTyped(ctx context.Context, ip string, opts ...CallOption) (*IPToDomainsResult, error) {
+	raw, err := c.IPToDomains(ctx, ip, opts...)
+	return typed(raw, err, NewIPToDomainsResult)
+}
+
+// DNSCheckerTyped is DNSChecker with a typed result.
+func (c *C99) DNSCheckerTyped(ctx context.Context, domain string, opts ...CallOption) (*DNSRecordsResult, error) {
+	raw, err := c.DNSChecker(ctx, domain, opts...)
+	return typed(raw, err, NewDNSRecordsResult)
+}
+
+// FirewallResolverTyped is FirewallResolver with a typed result.
+func (c *C99) FirewallResolverTyped(ctx context.Context, domain string, opts ...CallOption) (*FirewallResult, error) {
+	raw, err := c.FirewallResolver(ctx, domain, opts...)
+	return typed(raw, err, NewFirewallResult)
+}
@@ -0,0 +1,179 @@
+package c99
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// decodeRaw decodes a response the way the client does.
+func decodeRaw(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &raw); err != nil {
+		t.Fatalf("bad fixture %s: %v", s, err)
+	}
+	return raw
+}
+
+func TestNewSubdomainsResult(t *testing.T) {
+	r := NewSubdomainsResult(decodeRaw(t, `{"success":true,"subdomains":[
+		{"subdomain":"a.example.com","ip":"192.0.2.1","cloudflare":"true"},
+		{"subdomain":"b.example.com","ip":"none","cloudflare":false},
+		"c.example.com"]}`))
+	want := []Subdomain{
+		{Name: "a.example.com", IP: "192.0.2.1", Cloudflare: true},
+		{Name: "b.example.com"},
+		{Name: "c.example.com"},
+	}
+	if !r.Success || !reflect.DeepEqual(r.Subdomains, want) {
+		t.Errorf("got %+v, want %+v", r.Subdomains, want)
+	}
+}
+
+func TestNewPortScanResult(t *testing.T) {
+	for _, tc := range []struct {
+		raw  string
+		want []int
+	}{
+		{`{"success":true,"open_ports":[443,80,"8080"]}`, []int{80, 443, 8080}},
+		{`{"success":true,"open_ports":"80, 443"}`, []int{80, 443}},
+		{`{"success":true,"ports":"22"}`, []int{22}},
+		{`{"success":true,"open_ports":[]}`, nil},
+		{`{"success":true}`, nil},
+	} {
+		r := NewPortScanResult(decodeRaw(t, tc.raw))
+		if !reflect.DeepEqual(r.OpenPorts, tc.want) {
+			t.Errorf("%s: OpenPorts = %v, want %v", tc.raw, r.OpenPorts, tc.want)
+		}
+	}
+}
+
+func TestNewCheckPortResult(t *testing.T) {
+	for _, tc := range []struct {
+		raw  string
+		want bool
+	}{
+		{`{"success":true,"open":true}`, true},
+		{`{"success":true,"result":"open"}`, true},
+		{`{"success":true,"result":"Closed"}`, false},
+		{`{"success":true,"status":"1"}`, true},
+		{`{"success":true,"open_ports":[22,443]}`, true},
+		{`{"success":true,"open_ports":[22]}`, false},
+	} {
+		r := NewCheckPortResult(decodeRaw(t, tc.raw), 443)
+		if r.Open != tc.want || r.Port != 443 {
+			t.Errorf("%s: got %+v, want open=%v", tc.raw, r, tc.want)
+		}
+	}
+}
+
+func TestNewHostResults(t *testing.T) {
+	raw := decodeRaw(t, `{"success":true,"result":"host.example.com"}`)
+	if got := NewHostnameResult(raw).Hostname; got != "host.example.com" {
+		t.Errorf("Hostname = %q", got)
+	}
+	if got := NewPingResult(raw).Output; got != "host.example.com" {
+		t.Errorf("Output = %q", got)
+	}
+
+	ips := NewHostToIPResult(decodeRaw(t, `{"success":true,"ip":"192.0.2.1\n192.0.2.2"}`)).IPs
+	if want := []string{"192.0.2.1", "192.0.2.2"}; !reflect.DeepEqual(ips, want) {
+		t.Errorf("IPs = %q, want %q", ips, want)
+	}
+	domains := NewIPToDomainsResult(decodeRaw(t, `{"success":true,"data":["a.com",1,""]}`)).Domains
+	if want := []string{"a.com", "1"}; !reflect.DeepEqual(domains, want) {
+		t.Errorf("Domains = %q, want %q", domains, want)
+	}
+}
+
+func TestNewDNSRecordsResult(t *testing.T) {
+	r := NewDNSRecordsResult(decodeRaw(t, `{"success":true,"records":{
+		"a":["192.0.2.1"],"MX":"10 mx1.example.com","txt":null}}`))
+	want := map[string][]string{"A": {"192.0.2.1"}, "MX": {"10", "mx1.example.com"}, "TXT": nil}
+	if !reflect.DeepEqual(r.Records, want) {
+		t.Errorf("Records = %q, want %q", r.Records, want)
+	}
+}
+
+func TestNewFirewallResult(t *testing.T) {
+	for _, tc := range []struct {
+		raw      string
+		firewall string
+		detected bool
+	}{
+		{`{"success":true,"result":"Cloudflare"}`, "Cloudflare", true},
+		{`{"success":true,"result":"No firewall detected"}`, "", false},
+		{`{"success":true,"firewall":false}`, "", false},
+		{`{"success":true}`, "", false},
+	} {
+		r := NewFirewallResult(decodeRaw(t, tc.raw))
+		if r.Firewall != tc.firewall || r.Detected != tc.detected {
+			t.Errorf("%s: got %+v", tc.raw, r)
+		}
+	}
+}
+
+func TestResultFailure(t *testing.T) {
+	r := NewPortScanResult(decodeRaw(t, `{"success":"false","error":" Invalid host "}`))
+	if r.Success || r.Error != "Invalid host" {
+		t.Errorf("got %+v", r.Result)
+	}
+	if r.Raw["error"] != " Invalid host " {
+		t.Errorf("Raw not kept: %v", r.Raw)
+	}
+}
+
+func TestTypedMethod(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("host") == "bad" {
+			w.Write([]byte(`{"success":false,"error":"Invalid host"}`))
+			return
+		}
+		w.Write([]byte(`{"success":true,"open_ports":["443","80"]}`))
+	})
+	res, err := c.PortScannerTyped(context.Background(), "192.0.2.1")
+	if err != nil || !reflect.DeepEqual(res.OpenPorts, []int{80, 443}) {
+		t.Fatalf("PortScannerTyped = %+v, %v", res, err)
+	}
+	res, err = c.PortScannerTyped(context.Background(), "bad")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || res == nil || res.Error != "Invalid host" {
+		t.Fatalf("PortScannerTyped on failure = %+v, %v; want the result with an *APIError", res, err)
+	}
+}
+
+func TestLenientHelpers(t *testing.T) {
+	for _, tc := range []struct {
+		v    interface{}
+		want bool
+		ok   bool
+	}{
+		{true, true, true}, {"Yes", true, true}, {" 0 ", false, true}, {float64(2), true, true},
+		{json.Number("0"), false, true}, {"maybe", false, false}, {nil, false, false},
+	} {
+		if got, ok := asBool(tc.v); got != tc.want || ok != tc.ok {
+			t.Errorf("asBool(%#v) = %v, %v; want %v, %v", tc.v, got, ok, tc.want, tc.ok)
+		}
+	}
+	for _, tc := range []struct {
+		v    interface{}
+		want int
+		ok   bool
+	}{
+		{float64(42), 42, true}, {float64(4.5), 4, false}, {"17", 17, true}, {json.Number("8"), 8, true}, {"x", 0, false},
+	} {
+		if got, ok := asInt(tc.v); got != tc.want || ok != tc.ok {
+			t.Errorf("asInt(%#v) = %v, %v; want %v, %v", tc.v, got, ok, tc.want, tc.ok)
+		}
+	}
+	if got := asString(float64(1.5)); got != "1.5" {
+		t.Errorf("asString(1.5) = %q", got)
+	}
+	if v, ok := lookup(map[string]interface{}{"a": nil, "b": "x"}, "a", "b"); !ok || v != "x" {
+		t.Errorf("lookup skipped to %v, %v; want the first non-null key", v, ok)
+	}
+}
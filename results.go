@@ -0,0 +1,266 @@
+package c99
+
+import (
+	"encoding/json"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Result holds what every typed result has in common. The typed results
+// read the API's fields leniently: numbers and booleans sent as strings
+// are converted, and fields that are missing or of an unexpected type are
+// left at their zero value instead of failing.
+type Result struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+	// Raw is the response the result was decoded from, including fields
+	// without a typed counterpart. The finance results decode numbers in
+	// it as json.Number.
+	Raw map[string]interface{} `json:"-"`
+}
+
+func newResult(raw map[string]interface{}) Result {
+	success, _ := asBool(raw["success"])
+	return Result{Success: success, Error: asString(raw["error"]), Raw: raw}
+}
+
+// typed decodes the response of a method call into a typed result. The
+// error is passed through, so an *APIError comes with its result.
+func typed[T any](raw map[string]interface{}, err error, decode func(map[string]interface{}) *T) (*T, error) {
+	if raw == nil {
+		return nil, err
+	}
+	return decode(raw), err
+}
+
+// lookup returns the first of keys present in raw.
+func lookup(raw map[string]interface{}, keys ...string) (interface{}, bool) {
+	for _, k := range keys {
+		if v, ok := raw[k]; ok && v != nil {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// asString formats a scalar as a string; other values yield "".
+func asString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+// asBool reads a boolean sent as a JSON boolean, a number or a string
+// such as "true", "yes" or "1".
+func asBool(v interface{}) (bool, bool) {
+	switch v := v.(type) {
+	case bool:
+		return v, true
+	case float64:
+		return v != 0, true
+	case json.Number:
+		f, err := v.Float64()
+		return f != 0, err == nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "yes", "y", "1", "on":
+			return true, true
+		case "false", "no", "n", "0", "off":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// asInt reads an integer sent as a number or a string.
+func asInt(v interface{}) (int, bool) {
+	switch v := v.(type) {
+	case float64:
+		return int(v), v == float64(int(v))
+	case json.Number:
+		n, err := strconv.Atoi(v.String())
+		return n, err == nil
+	case string:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// asStringList reads a list of scalars, or a single string holding values
+// separated by commas, whitespace or newlines.
+func asStringList(v interface{}) []string {
+	var out []string
+	switch v := v.(type) {
+	case []interface{}:
+		for _, e := range v {
+			if s := asString(e); s != "" {
+				out = append(out, s)
+			}
+		}
+	case string:
+		out = strings.FieldsFunc(v, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+		})
+	}
+	return out
+}
+
+// asList reads a list of scalars, or a single string holding values
+// separated by any of the characters in seps. Unlike asStringList it keeps
+// values containing spaces, such as definitions or phrases.
+func asList(v interface{}, seps string) []string {
+	var out []string
+	switch v := v.(type) {
+	case []interface{}:
+		for _, e := range v {
+			if s := asString(e); s != "" {
+				out = append(out, s)
+			}
+		}
+	case string:
+		for _, s := range strings.FieldsFunc(v, func(r rune) bool { return strings.ContainsRune(seps, r) }) {
+			if s = strings.TrimSpace(s); s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// asCount reads a count such as a number of views, sent as a number or as
+// a string like "1234567", "1,234,567 views" or "1.2M".
+func asCount(v interface{}) (int, bool) {
+	var s string
+	switch v := v.(type) {
+	case float64:
+		return int(math.Round(v)), true
+	case json.Number:
+		s = v.String()
+	case string:
+		s = v
+	default:
+		return 0, false
+	}
+	f := strings.Fields(strings.ToLower(s))
+	if len(f) == 0 {
+		return 0, false
+	}
+	s = strings.NewReplacer(",", "", "_", "").Replace(f[0])
+	mult := 1.0
+	switch {
+	case strings.HasSuffix(s, "k"):
+		mult = 1e3
+	case strings.HasSuffix(s, "m"):
+		mult = 1e6
+	case strings.HasSuffix(s, "b"):
+		mult = 1e9
+	}
+	if mult != 1 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return int(math.Round(n * mult)), true
+}
+
+// asDuration reads a duration sent as a number of seconds, a clock time
+// such as "3:21" or "1:02:03", an ISO 8601 duration such as "PT3M21S" or a
+// Go duration such as "3m21s".
+func asDuration(v interface{}) (time.Duration, bool) {
+	var s string
+	switch v := v.(type) {
+	case float64:
+		return time.Duration(v * float64(time.Second)), true
+	case json.Number:
+		s = v.String()
+	case string:
+		s = strings.TrimSpace(v)
+	default:
+		return 0, false
+	}
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(secs * float64(time.Second)), true
+	}
+	if strings.Contains(s, ":") {
+		var d time.Duration
+		for _, part := range strings.Split(s, ":") {
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 0 {
+				return 0, false
+			}
+			d = d*60 + time.Duration(n)
+		}
+		return d * time.Second, true
+	}
+	if u := strings.ToUpper(s); strings.HasPrefix(u, "P") {
+		return parseISODuration(u)
+	}
+	d, err := time.ParseDuration(s)
+	return d, err == nil
+}
+
+// parseISODuration parses an ISO 8601 duration made of days, hours,
+// minutes and seconds.
+func parseISODuration(s string) (time.Duration, bool) {
+	units := map[byte]time.Duration{'D': 24 * time.Hour, 'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+	var d time.Duration
+	num, inTime := "", false
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == 'T' && num == "":
+			inTime = true
+		case c >= '0' && c <= '9' || c == '.':
+			num += string(c)
+		case c == 'M' && !inTime:
+			// Months have no fixed length.
+			return 0, false
+		case units[c] != 0 && num != "":
+			n, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return 0, false
+			}
+			d += time.Duration(n * float64(units[c]))
+			num = ""
+		default:
+			return 0, false
+		}
+	}
+	return d, num == ""
+}
+
+// asURL reads an absolute URL; protocol-relative URLs get https. Other
+// values yield nil.
+func asURL(v interface{}) *url.URL {
+	s := asString(v)
+	if strings.HasPrefix(s, "//") {
+		s = "https:" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil
+	}
+	return u
+}
+
+// urlString formats u, or returns "" for nil.
+func urlString(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	return u.String()
+}
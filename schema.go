@@ -0,0 +1,242 @@
+package c99
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"c99/internal/jsonvalue"
+)
+
+// Schema is the subset of JSON Schema used to describe response shapes:
+// "type", "properties", "required", "items" and "additionalProperties".
+type Schema struct {
+	Type       schemaTypes        `json:"type,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	// AdditionalProperties covers keys not listed in Properties. Unlike
+	// in JSON Schema, unlisted keys are reported as added unless it is
+	// set, either to true or to a schema for their values, as suits
+	// objects keyed by data such as currency codes.
+	AdditionalProperties *additionalProperties `json:"additionalProperties,omitempty"`
+}
+
+// schemaTypes is the "type" keyword, a single type name or a list.
+type schemaTypes []string
+
+func (t schemaTypes) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = schemaTypes{one}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// additionalProperties is the "additionalProperties" keyword, a boolean or
+// a schema.
+type additionalProperties struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+func (a *additionalProperties) MarshalJSON() ([]byte, error) {
+	if a.Schema != nil {
+		return json.Marshal(a.Schema)
+	}
+	return json.Marshal(a.Allowed)
+}
+
+func (a *additionalProperties) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.Allowed); err == nil {
+		return nil
+	}
+	a.Allowed, a.Schema = true, new(Schema)
+	return json.Unmarshal(data, a.Schema)
+}
+
+// SchemaDrift is one way a response departs from its schema. Path is in
+// the syntax accepted by ParsePath, with "[]" standing for every element
+// of a list.
+type SchemaDrift struct {
+	Kind     string `json:"kind"` // "added", "removed" or "retyped"
+	Path     string `json:"path"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+func (d SchemaDrift) String() string {
+	switch d.Kind {
+	case "added":
+		return fmt.Sprintf("added    %s (%s)", d.Path, d.Actual)
+	case "removed":
+		return fmt.Sprintf("removed  %s (%s)", d.Path, d.Expected)
+	}
+	return fmt.Sprintf("retyped  %s (%s -> %s)", d.Path, d.Expected, d.Actual)
+}
+
+// jsonType names the JSON type of a decoded value.
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64, json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// allows reports whether the schema's type admits v.
+func (s *Schema) allows(v interface{}) bool {
+	if len(s.Type) == 0 {
+		return true
+	}
+	actual := jsonType(v)
+	if slices.Contains(s.Type, actual) {
+		return true
+	}
+	if actual == "number" && slices.Contains(s.Type, "integer") {
+		f, ok := toNumber(v)
+		return ok && f == math.Trunc(f)
+	}
+	return false
+}
+
+// Validate returns the ways v departs from the schema, in path order.
+func (s *Schema) Validate(v interface{}) []SchemaDrift {
+	var drifts []SchemaDrift
+	s.validate(&drifts, "", v)
+	sort.SliceStable(drifts, func(i, j int) bool { return drifts[i].Path < drifts[j].Path })
+	// Elements of a list report the same drift under the same path.
+	return slices.Compact(drifts)
+}
+
+func (s *Schema) validate(drifts *[]SchemaDrift, path string, v interface{}) {
+	if !s.allows(v) {
+		*drifts = append(*drifts, SchemaDrift{Kind: "retyped", Path: rootPath(path),
+			Expected: strings.Join(s.Type, "|"), Actual: jsonType(v)})
+		return
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, k := range s.Required {
+			if _, ok := v[k]; !ok {
+				expected := ""
+				if p := s.Properties[k]; p != nil {
+					expected = strings.Join(p.Type, "|")
+				}
+				*drifts = append(*drifts, SchemaDrift{Kind: "removed", Path: path + pathKey(k), Expected: expected})
+			}
+		}
+		for _, k := range jsonvalue.SortedKeys(v) {
+			p := path + pathKey(k)
+			switch prop, ok := s.Properties[k]; {
+			case ok:
+				prop.validate(drifts, p, v[k])
+			case s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil:
+				s.AdditionalProperties.Schema.validate(drifts, p, v[k])
+			case s.AdditionalProperties == nil || !s.AdditionalProperties.Allowed:
+				*drifts = append(*drifts, SchemaDrift{Kind: "added", Path: p, Actual: jsonType(v[k])})
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for _, e := range v {
+				s.Items.validate(drifts, rootPath(path)+"[]", e)
+			}
+		}
+	}
+}
+
+// InferSchema returns a schema describing v: every field present is
+// listed and required, and list elements share one schema that admits
+// all of them. An empty list gets "items": {}, admitting any element,
+// since there is nothing to infer from.
+func InferSchema(v interface{}) *Schema {
+	s := &Schema{Type: schemaTypes{jsonType(v)}}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		s.Properties = make(map[string]*Schema, len(v))
+		for k, e := range v {
+			s.Properties[k] = InferSchema(e)
+		}
+		s.Required = jsonvalue.SortedKeys(v)
+	case []interface{}:
+		for _, e := range v {
+			s.Items = mergeSchemas(s.Items, InferSchema(e))
+		}
+		if s.Items == nil {
+			s.Items = &Schema{}
+		}
+	}
+	return s
+}
+
+// empty reports whether s is the empty schema {}, which admits anything.
+// InferSchema gives it to the elements of an empty list.
+func (s *Schema) empty() bool {
+	return len(s.Type) == 0 && s.Properties == nil && s.Required == nil &&
+		s.Items == nil && s.AdditionalProperties == nil
+}
+
+// mergeSchemas returns a schema admitting what either a or b admits.
+func mergeSchemas(a, b *Schema) *Schema {
+	if a == nil {
+		return b
+	}
+	if a.empty() || b.empty() {
+		return &Schema{}
+	}
+	m := &Schema{}
+	// A schema without a type admits any type, and so does the merge.
+	if len(a.Type) > 0 && len(b.Type) > 0 {
+		types := append(slices.Clone(a.Type), b.Type...)
+		sort.Strings(types)
+		m.Type = slices.Compact(types)
+	}
+
+	if a.Properties != nil || b.Properties != nil {
+		m.Properties = make(map[string]*Schema)
+		for k, p := range a.Properties {
+			m.Properties[k] = p
+		}
+		for k, p := range b.Properties {
+			m.Properties[k] = mergeSchemas(m.Properties[k], p)
+		}
+	}
+	// A field is only required if every element has it.
+	for _, k := range a.Required {
+		if slices.Contains(b.Required, k) {
+			m.Required = append(m.Required, k)
+		}
+	}
+
+	switch {
+	case a.Items == nil:
+		m.Items = b.Items
+	case b.Items == nil:
+		m.Items = a.Items
+	default:
+		m.Items = mergeSchemas(a.Items, b.Items)
+	}
+	return m
+}
@@ -0,0 +1,75 @@
+package c99
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestInferSchema(t *testing.T) {
+	s := InferSchema(decodeRaw(t, `{"success":true,"ports":[80,"ssh"],"data":[],
+		"records":[{"a":1,"b":"x"},{"a":2}]}`))
+	got, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"object","properties":{` +
+		`"data":{"type":"array","items":{}},` +
+		`"ports":{"type":"array","items":{"type":["number","string"]}},` +
+		`"records":{"type":"array","items":{"type":"object","properties":{"a":{"type":"number"},"b":{"type":"string"}},"required":["a"]}},` +
+		`"success":{"type":"boolean"}},` +
+		`"required":["data","ports","records","success"]}`
+	if string(got) != want {
+		t.Errorf("InferSchema =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	s := InferSchema(decodeRaw(t, `{"success":true,"count":1,"data":[],"ports":[80]}`))
+	drifts := s.Validate(decodeRaw(t, `{"success":"true","data":[1,"a",{}],"ports":["x"],"extra":null}`))
+	want := []SchemaDrift{
+		{Kind: "removed", Path: ".count", Expected: "number"},
+		{Kind: "added", Path: ".extra", Actual: "null"},
+		{Kind: "retyped", Path: ".ports[]", Expected: "number", Actual: "string"},
+		{Kind: "retyped", Path: ".success", Expected: "boolean", Actual: "string"},
+	}
+	if !reflect.DeepEqual(drifts, want) {
+		t.Errorf("Validate = %+v, want %+v", drifts, want)
+	}
+	if drifts := s.Validate(decodeRaw(t, `{"success":false,"count":2,"data":[],"ports":[]}`)); len(drifts) != 0 {
+		t.Errorf("Validate of a matching value = %+v", drifts)
+	}
+}
+
+func TestInferSchemaEmptyItems(t *testing.T) {
+	for _, tc := range []struct {
+		v    string
+		want string
+	}{
+		{`[[],[1]]`, `{"type":"array","items":{"type":"array","items":{}}}`},
+		{`[[1],[]]`, `{"type":"array","items":{"type":"array","items":{}}}`},
+		{`[[{"a":1}],[]]`, `{"type":"array","items":{"type":"array","items":{}}}`},
+		{`[[1],["x"]]`, `{"type":"array","items":{"type":"array","items":{"type":["number","string"]}}}`},
+	} {
+		got, err := json.Marshal(InferSchema(decodeJSON(t, tc.v)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("InferSchema(%s) = %s, want %s", tc.v, got, tc.want)
+		}
+	}
+
+	// A schema without a type keeps admitting any type once merged.
+	var untyped Schema
+	if err := json.Unmarshal([]byte(`{"properties":{"a":{"type":"number"}}}`), &untyped); err != nil {
+		t.Fatal(err)
+	}
+	m := mergeSchemas(&untyped, &Schema{Type: schemaTypes{"object"}})
+	if len(m.Type) != 0 || m.Properties["a"] == nil {
+		t.Errorf("merged schema = %+v, want no type and the properties", m)
+	}
+	if drifts := InferSchema(decodeJSON(t, `[[],[1]]`)).Validate(decodeJSON(t, `[["x",{}]]`)); len(drifts) != 0 {
+		t.Errorf("Validate = %+v, want any element admitted", drifts)
+	}
+}
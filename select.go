@@ -0,0 +1,475 @@
+package c99
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"c99/internal/jsonvalue"
+	"c99/internal/quoted"
+)
+
+// Path is a compiled selector over a decoded result, in a small jq-like
+// syntax: ".subdomains[].ip", ".records.A[0]", `.["odd key"]` or "." for
+// the whole value. "[]" and "[*]" iterate over a list or object, and
+// make the path yield a list of every match.
+type Path struct {
+	expr  string
+	steps []pathStep
+}
+
+type pathStep struct {
+	key     string
+	index   int
+	isIndex bool
+	iterate bool
+}
+
+// ParsePath compiles a path expression.
+func ParsePath(expr string) (*Path, error) {
+	p := &Path{expr: expr}
+	s := strings.TrimSpace(expr)
+	s = strings.TrimPrefix(s, "$")
+	if s == "" || s == "." {
+		return p, nil
+	}
+	if s[0] != '.' && s[0] != '[' {
+		s = "." + s
+	}
+
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			end := strings.IndexAny(s, ".[]")
+			if end < 0 {
+				end = len(s)
+			}
+			if end == 0 {
+				if len(s) > 0 && s[0] == '[' {
+					continue
+				}
+				return nil, fmt.Errorf("invalid path %q: empty field name", expr)
+			}
+			p.steps = append(p.steps, pathStep{key: s[:end]})
+			s = s[end:]
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: missing ]", expr)
+			}
+			inner := strings.TrimSpace(s[1:end])
+			s = s[end+1:]
+			switch {
+			case inner == "" || inner == "*":
+				p.steps = append(p.steps, pathStep{iterate: true})
+			case inner[0] == '"':
+				key, err := strconv.Unquote(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid path %q: bad quoted key %s", expr, inner)
+				}
+				p.steps = append(p.steps, pathStep{key: key})
+			default:
+				n, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid path %q: bad index %s", expr, inner)
+				}
+				p.steps = append(p.steps, pathStep{index: n, isIndex: true})
+			}
+		default:
+			return nil, fmt.Errorf("invalid path %q at %q", expr, s)
+		}
+	}
+	return p, nil
+}
+
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Eval applies the path to v. Missing fields yield nil; when the path
+// iterates, missing matches are left out of the returned list.
+func (p *Path) Eval(v interface{}) interface{} {
+	values := []interface{}{v}
+	multi := false
+	for _, step := range p.steps {
+		var next []interface{}
+		for _, cur := range values {
+			switch {
+			case step.iterate:
+				switch c := cur.(type) {
+				case []interface{}:
+					next = append(next, c...)
+				case map[string]interface{}:
+					for _, k := range jsonvalue.SortedKeys(c) {
+						next = append(next, c[k])
+					}
+				}
+			case step.isIndex:
+				if list, ok := cur.([]interface{}); ok {
+					i := step.index
+					if i < 0 {
+						i += len(list)
+					}
+					if i >= 0 && i < len(list) {
+						next = append(next, list[i])
+						continue
+					}
+				}
+				if !multi {
+					next = append(next, nil)
+				}
+			default:
+				if obj, ok := cur.(map[string]interface{}); ok {
+					if val, ok := obj[step.key]; ok {
+						next = append(next, val)
+						continue
+					}
+				}
+				if !multi {
+					next = append(next, nil)
+				}
+			}
+		}
+		if step.iterate {
+			multi = true
+		}
+		values = next
+	}
+
+	if multi {
+		if values == nil {
+			return []interface{}{}
+		}
+		return values
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return values[0]
+}
+
+// apply replaces every value the path matches in v, which is modified in
+// place, with fn's result. A missing field at the end of the path is added
+// unless fn(nil) is nil; other missing parts of the path are left alone.
+func (p *Path) apply(v interface{}, fn func(interface{}) interface{}) interface{} {
+	return applySteps(p.steps, v, fn)
+}
+
+func applySteps(steps []pathStep, v interface{}, fn func(interface{}) interface{}) interface{} {
+	if len(steps) == 0 {
+		return fn(v)
+	}
+	step, rest := steps[0], steps[1:]
+	switch c := v.(type) {
+	case []interface{}:
+		switch {
+		case step.iterate:
+			for i := range c {
+				c[i] = applySteps(rest, c[i], fn)
+			}
+		case step.isIndex:
+			i := step.index
+			if i < 0 {
+				i += len(c)
+			}
+			if i >= 0 && i < len(c) {
+				c[i] = applySteps(rest, c[i], fn)
+			}
+		}
+	case map[string]interface{}:
+		switch {
+		case step.iterate:
+			for k, e := range c {
+				c[k] = applySteps(rest, e, fn)
+			}
+		case step.isIndex:
+		default:
+			if e, ok := c[step.key]; ok {
+				c[step.key] = applySteps(rest, e, fn)
+			} else if len(rest) == 0 {
+				if e := fn(nil); e != nil {
+					c[step.key] = e
+				}
+			}
+		}
+	}
+	return v
+}
+
+// Select evaluates the path expression expr against result.
+func Select(result interface{}, expr string) (interface{}, error) {
+	p, err := ParsePath(expr)
+	if err != nil {
+		return nil, err
+	}
+	return p.Eval(result), nil
+}
+
+// Filter is a compiled --where condition such as `.ip != "none"`,
+// `. < 1024` or `.cloudflare`. Conditions compare a path against a
+// literal with ==, !=, <, <=, >, >=, =~ (regexp match) or !~, and may be
+// combined with && and ||. A bare path tests for a truthy value and a
+// leading ! negates it.
+type Filter struct {
+	expr string
+	// any holds alternatives joined by ||, each a list of conditions
+	// joined by &&.
+	any [][]condition
+}
+
+type condition struct {
+	path    *Path
+	op      string
+	literal interface{}
+	re      *regexp.Regexp
+	negate  bool
+}
+
+// filterOps is ordered so that two-character operators match first.
+var filterOps = []string{"==", "!=", "<=", ">=", "=~", "!~", "<", ">"}
+
+// ParseFilter compiles a filter expression.
+func ParseFilter(expr string) (*Filter, error) {
+	f := &Filter{expr: expr}
+	for _, alt := range quoted.Split(expr, "||") {
+		var conds []condition
+		for _, part := range quoted.Split(alt, "&&") {
+			cond, err := parseCondition(strings.TrimSpace(part))
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter %q: %v", expr, err)
+			}
+			conds = append(conds, cond)
+		}
+		f.any = append(f.any, conds)
+	}
+	return f, nil
+}
+
+func parseCondition(s string) (condition, error) {
+	if s == "" {
+		return condition{}, fmt.Errorf("empty condition")
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' {
+			// Skip a quoted key such as .["a<b"].
+			for i++; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' {
+					i++
+				}
+			}
+			continue
+		}
+		for _, op := range filterOps {
+			if !strings.HasPrefix(s[i:], op) {
+				continue
+			}
+			path, err := ParsePath(s[:i])
+			if err != nil {
+				return condition{}, err
+			}
+			rhs := strings.TrimSpace(s[i+len(op):])
+			if rhs == "" {
+				return condition{}, fmt.Errorf("missing value after %s", op)
+			}
+			cond := condition{path: path, op: op, literal: parseLiteral(rhs)}
+			if op == "=~" || op == "!~" {
+				cond.re, err = regexp.Compile(jsonvalue.Scalar(cond.literal))
+				if err != nil {
+					return condition{}, err
+				}
+			}
+			return cond, nil
+		}
+	}
+
+	negate := strings.HasPrefix(s, "!")
+	path, err := ParsePath(strings.TrimPrefix(s, "!"))
+	if err != nil {
+		return condition{}, err
+	}
+	return condition{path: path, negate: negate}, nil
+}
+
+// parseLiteral reads a quoted string, number, boolean or null; anything
+// else is taken as a bare string.
+func parseLiteral(s string) interface{} {
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		return unquoted
+	}
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return n
+	}
+	return s
+}
+
+func (f *Filter) String() string {
+	return f.expr
+}
+
+// Match reports whether v satisfies the filter.
+func (f *Filter) Match(v interface{}) bool {
+	for _, conds := range f.any {
+		all := true
+		for _, cond := range conds {
+			if !cond.match(v) {
+				all = false
+				break
+			}
+		}
+		if all {
+			return true
+		}
+	}
+	return false
+}
+
+func (c condition) match(v interface{}) bool {
+	val := c.path.Eval(v)
+	switch c.op {
+	case "":
+		return truthy(val) != c.negate
+	case "=~":
+		return val != nil && c.re.MatchString(jsonvalue.Scalar(val))
+	case "!~":
+		return val == nil || !c.re.MatchString(jsonvalue.Scalar(val))
+	}
+
+	cmp, ok := compareValues(val, c.literal)
+	switch c.op {
+	case "==":
+		return ok && cmp == 0
+	case "!=":
+		return !ok || cmp != 0
+	case "<":
+		return ok && cmp < 0
+	case "<=":
+		return ok && cmp <= 0
+	case ">":
+		return ok && cmp > 0
+	case ">=":
+		return ok && cmp >= 0
+	}
+	return false
+}
+
+// compareValues orders a against b, numerically when both read as numbers
+// (C99 often sends numbers as strings) and as text otherwise. It reports
+// false when either side is missing.
+func compareValues(a, b interface{}) (int, bool) {
+	if a == nil || b == nil {
+		if a == nil && b == nil {
+			return 0, true
+		}
+		return 0, false
+	}
+	if x, ok := toNumber(a); ok {
+		if y, ok := toNumber(b); ok {
+			switch {
+			case x < y:
+				return -1, true
+			case x > y:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+	return strings.Compare(jsonvalue.Scalar(a), jsonvalue.Scalar(b)), true
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// truthy treats nil, false, "", "false", 0 and empty containers as false.
+func truthy(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case string:
+		return val != "" && !strings.EqualFold(val, "false")
+	case float64:
+		return val != 0
+	case json.Number:
+		f, ok := toNumber(val)
+		return !ok || f != 0
+	case []interface{}:
+		return len(val) > 0
+	case map[string]interface{}:
+		return len(val) > 0
+	}
+	return true
+}
+
+// Where filters the elements of result's main list (a top-level list, or
+// the longest list field of an object) down to those matching every
+// filter expression. The result itself is left unmodified.
+func Where(result interface{}, exprs ...string) (interface{}, error) {
+	filters := make([]*Filter, len(exprs))
+	for i, expr := range exprs {
+		f, err := ParseFilter(expr)
+		if err != nil {
+			return nil, err
+		}
+		filters[i] = f
+	}
+	return ApplyFilters(result, filters), nil
+}
+
+// ApplyFilters is Where for filters already parsed.
+func ApplyFilters(result interface{}, filters []*Filter) interface{} {
+	if len(filters) == 0 {
+		return result
+	}
+	key, list := jsonvalue.PrimaryList(result)
+	if list == nil {
+		return result
+	}
+
+	kept := []interface{}{}
+	for _, item := range list {
+		ok := true
+		for _, f := range filters {
+			if !f.Match(item) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			kept = append(kept, item)
+		}
+	}
+
+	obj, isObj := result.(map[string]interface{})
+	if !isObj {
+		return kept
+	}
+	out := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		out[k] = v
+	}
+	out[key] = kept
+	return out
+}
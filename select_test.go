@@ -0,0 +1,257 @@
+package c99
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const selectFixture = `{
+	"success": true,
+	"count": "3",
+	"subdomains": [
+		{"subdomain": "a.example.com", "ip": "192.0.2.1", "cloudflare": true, "port": 443},
+		{"subdomain": "b.example.com", "ip": "none", "cloudflare": false, "port": "80"},
+		{"subdomain": "c.example.com", "cloudflare": "false", "port": 8080}
+	],
+	"records": {"A": ["192.0.2.1", "192.0.2.2"], "MX": []},
+	"odd key": {"a.b": 1}
+}`
+
+func TestParsePathEval(t *testing.T) {
+	v := decodeRaw(t, selectFixture)
+	for _, tc := range []struct {
+		expr string
+		want string
+	}{
+		{".", selectFixture},
+		{"", selectFixture},
+		{"$", selectFixture},
+		{".success", `true`},
+		{"success", `true`},
+		{"$.count", `"3"`},
+		{".missing", `null`},
+		{".success.deeper", `null`},
+		{".records.A[0]", `"192.0.2.1"`},
+		{".records.A[-1]", `"192.0.2.2"`},
+		{".records.A[2]", `null`},
+		{".records[\"A\"][ 1 ]", `"192.0.2.2"`},
+		{`.["odd key"]["a.b"]`, `1`},
+		{".subdomains[].ip", `["192.0.2.1","none"]`},
+		{".subdomains[*].subdomain", `["a.example.com","b.example.com","c.example.com"]`},
+		{".subdomains[1].ip", `"none"`},
+		{".records[]", `[["192.0.2.1","192.0.2.2"],[]]`},
+		{".records[][]", `["192.0.2.1","192.0.2.2"]`},
+		{".records[][0]", `["192.0.2.1"]`},
+		{".records.MX[]", `[]`},
+		{".missing[]", `[]`},
+		{"[0]", `null`},
+	} {
+		p, err := ParsePath(tc.expr)
+		if err != nil {
+			t.Errorf("ParsePath(%q): %v", tc.expr, err)
+			continue
+		}
+		if p.String() != tc.expr {
+			t.Errorf("String() = %q, want %q", p.String(), tc.expr)
+		}
+		if got, want := p.Eval(v), decodeJSON(t, tc.want); !reflect.DeepEqual(got, want) {
+			b, _ := json.Marshal(got)
+			t.Errorf("Eval(%q) = %s, want %s", tc.expr, b, tc.want)
+		}
+	}
+
+	// A top-level list can be indexed and iterated directly.
+	list := decodeJSON(t, `[{"n":1},{"n":2}]`)
+	if got, _ := Select(list, "[1].n"); got != float64(2) {
+		t.Errorf("Select([1].n) = %v", got)
+	}
+	if got, _ := Select(list, ".[].n"); !reflect.DeepEqual(got, []interface{}{float64(1), float64(2)}) {
+		t.Errorf("Select(.[].n) = %v", got)
+	}
+}
+
+func decodeJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("bad fixture %s: %v", s, err)
+	}
+	return v
+}
+
+func TestParsePathErrors(t *testing.T) {
+	for _, tc := range []struct{ expr, err string }{
+		{".a..b", "empty field name"},
+		{".a.", "empty field name"},
+		{".a[0", "missing ]"},
+		{".a[x]", "bad index x"},
+		{`.["a]`, "bad quoted key"},
+		{".a]", `at "]"`},
+	} {
+		_, err := ParsePath(tc.expr)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("ParsePath(%q) error = %v, want %q", tc.expr, err, tc.err)
+		}
+		if _, serr := Select(nil, tc.expr); serr == nil {
+			t.Errorf("Select(%q) did not fail", tc.expr)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	v := decodeRaw(t, selectFixture)
+	subs := v["subdomains"].([]interface{})
+	for _, tc := range []struct {
+		expr string
+		want []int // indexes into subs that match
+	}{
+		{".cloudflare", []int{0}},
+		{"!.cloudflare", []int{1, 2}},
+		{".ip", []int{0, 1}},
+		{"!.ip", []int{2}},
+		{`.ip != "none"`, []int{0, 2}},
+		{`.ip == "none"`, []int{1}},
+		{`.ip == none`, []int{1}},
+		{".ip == null", []int{2}},
+		{".ip != null", []int{0, 1}},
+		{".port < 1024", []int{0, 1}},
+		{".port <= 443", []int{0, 1}},
+		{".port > 443", []int{2}},
+		{".port >= 443", []int{0, 2}},
+		{".port == 80", []int{1}},
+		{`.port == "0080"`, []int{1}},
+		{".cloudflare == true", []int{0}},
+		{".cloudflare == false", []int{1, 2}},
+		{`.subdomain =~ "^[ab]\\."`, []int{0, 1}},
+		{`.subdomain !~ ^a`, []int{1, 2}},
+		{`.ip =~ .`, []int{0, 1}},
+		{`.ip !~ none`, []int{0, 2}},
+		// A number and a string that is not one compare as text.
+		{`.ip < 5`, []int{0}},
+		{`.ip > 5`, []int{1}},
+		// Missing values only satisfy != and !~.
+		{".missing < 1", nil},
+		{".missing != 1", []int{0, 1, 2}},
+		{`.port > 100 && .cloudflare`, []int{0}},
+		{`.port == 80 || .port == 8080`, []int{1, 2}},
+		{`.ip == "none" || .port > 1000 && .cloudflare == "false"`, []int{1, 2}},
+		{`.subdomain == "a.example.com||x"`, nil},
+		{`.subdomain != "&&"`, []int{0, 1, 2}},
+		{`.["subdomain"] == "c.example.com"`, []int{2}},
+	} {
+		f, err := ParseFilter(tc.expr)
+		if err != nil {
+			t.Errorf("ParseFilter(%q): %v", tc.expr, err)
+			continue
+		}
+		var got []int
+		for i, s := range subs {
+			if f.Match(s) {
+				got = append(got, i)
+			}
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s matched %v, want %v", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	for _, tc := range []struct{ expr, err string }{
+		{"", "empty condition"},
+		{".a == 1 &&", "empty condition"},
+		{"|| .a", "empty condition"},
+		{".a[ == 1", "missing ]"},
+		{".a..b", "empty field name"},
+		{".a =~ (", "missing closing )"},
+		{".a ==", "missing value after =="},
+		{`.["a"] <`, "missing value after <"},
+	} {
+		_, err := ParseFilter(tc.expr)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("ParseFilter(%q) error = %v, want %q", tc.expr, err, tc.err)
+		}
+	}
+}
+
+func TestCompareValues(t *testing.T) {
+	for _, tc := range []struct {
+		a, b interface{}
+		cmp  int
+		ok   bool
+	}{
+		{float64(1), float64(2), -1, true},
+		{"10", float64(9), 1, true},
+		{" 10 ", "10.0", 0, true},
+		{json.Number("10"), float64(9), 1, true},
+		{json.Number("0.30000000000000001"), "0.3", 0, true},
+		{"10", "9x", -1, true},
+		{"b", "a", 1, true},
+		{true, "true", 0, true},
+		{false, true, -1, true},
+		{nil, nil, 0, true},
+		{nil, float64(0), 0, false},
+		{"", nil, 0, false},
+		{[]interface{}{}, "x", -1, true},
+	} {
+		cmp, ok := compareValues(tc.a, tc.b)
+		if cmp != tc.cmp || ok != tc.ok {
+			t.Errorf("compareValues(%#v, %#v) = %d, %v; want %d, %v", tc.a, tc.b, cmp, ok, tc.cmp, tc.ok)
+		}
+	}
+}
+
+func TestWhere(t *testing.T) {
+	v := decodeRaw(t, selectFixture)
+	got, err := Where(v, ".port < 1024", `.ip != "none"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subs := got.(map[string]interface{})["subdomains"].([]interface{})
+	if len(subs) != 1 || subs[0].(map[string]interface{})["subdomain"] != "a.example.com" {
+		t.Errorf("Where kept %v", subs)
+	}
+	if len(v["subdomains"].([]interface{})) != 3 {
+		t.Error("Where modified its argument")
+	}
+	if got, _ := Where(decodeJSON(t, `[1,5,10]`), ". > 2"); !reflect.DeepEqual(got, []interface{}{float64(5), float64(10)}) {
+		t.Errorf("Where on a list = %v", got)
+	}
+	if got, _ := Where(decodeJSON(t, `{"a":1}`), ". > 2"); !reflect.DeepEqual(got, map[string]interface{}{"a": float64(1)}) {
+		t.Errorf("Where without a list = %v", got)
+	}
+	if _, err := Where(v, ".port > 1", ".a =="); err == nil {
+		t.Error("Where accepted a bad filter")
+	}
+}
+
+func TestTruthy(t *testing.T) {
+	for _, tc := range []struct {
+		v    interface{}
+		want bool
+	}{
+		{nil, false}, {false, false}, {true, true},
+		{"", false}, {"False", false}, {"0", true}, {"x", true},
+		{float64(0), false}, {float64(-1), true},
+		{json.Number("0"), false}, {json.Number("0.00"), false}, {json.Number("0.001"), true},
+		{[]interface{}{}, false}, {[]interface{}{nil}, true},
+		{map[string]interface{}{}, false}, {map[string]interface{}{"a": nil}, true},
+	} {
+		if got := truthy(tc.v); got != tc.want {
+			t.Errorf("truthy(%#v) = %v, want %v", tc.v, got, tc.want)
+		}
+	}
+
+	// Finance results decoded exactly hold json.Number values.
+	v := decodeExact(t, `{"balances":[{"coin":"BTC","amount":0},{"coin":"ETH","amount":1.5}]}`)
+	got, err := Where(v, ".amount")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list := got.(map[string]interface{})["balances"].([]interface{}); len(list) != 1 ||
+		list[0].(map[string]interface{})["coin"] != "ETH" {
+		t.Errorf("Where(.amount) kept %v", list)
+	}
+}
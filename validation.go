@@ -0,0 +1,235 @@
+package c99
+
+import (
+	"context"
+	"strings"
+)
+
+// Verdict is implemented by the results of methods that check their input,
+// such as EmailValidator or TorChecker, so that they can be handled alike.
+type Verdict interface {
+	// Passed reports whether the input passed the check: a valid address,
+	// or one that is not disposable, a Tor exit node, a proxy or
+	// malicious. known is false when the response gave no answer.
+	Passed() (passed, known bool)
+	// Explanation returns the reason the API gave for its answer, if any.
+	Explanation() string
+}
+
+// Check is embedded in the results that implement Verdict.
+type Check struct {
+	Reason string `json:"reason,omitempty"`
+
+	passed, known bool
+}
+
+func (c Check) Passed() (passed, known bool) { return c.passed, c.known }
+func (c Check) Explanation() string          { return c.Reason }
+
+func newCheck(raw map[string]interface{}) Check {
+	v, _ := lookup(raw, "reason", "message", "details", "description")
+	return Check{Reason: asString(v)}
+}
+
+// flag reads a yes/no field under the first of keys present. Besides the
+// usual boolean spellings, words maps field-specific answers such as
+// "invalid" or "clean" to their value.
+func flag(raw map[string]interface{}, words map[string]bool, keys ...string) (value, known bool) {
+	v, ok := lookup(raw, keys...)
+	if !ok {
+		return false, false
+	}
+	if b, ok := asBool(v); ok {
+		return b, true
+	}
+	value, known = words[strings.ToLower(asString(v))]
+	return value, known
+}
+
+var (
+	validWords = map[string]bool{"valid": true, "invalid": false, "not valid": false}
+	foundWords = map[string]bool{"detected": true, "not detected": false, "found": true, "not found": false}
+	// maliciousWords answer "is it malicious?".
+	maliciousWords = map[string]bool{
+		"malicious": true, "phishing": true, "malware": true, "suspicious": true,
+		"unsafe": true, "dangerous": true, "blacklisted": true,
+		"clean": false, "safe": false, "harmless": false, "good": false, "unrated": false,
+	}
+)
+
+// EmailResult is the typed result of EmailValidator.
+type EmailResult struct {
+	Result
+	Check
+	IsValid bool `json:"is_valid"`
+	// IsDisposable is set when the response also says whether the address
+	// belongs to a disposable mail service.
+	IsDisposable bool `json:"is_disposable"`
+}
+
+// NewEmailResult decodes an EmailValidator response.
+func NewEmailResult(raw map[string]interface{}) *EmailResult {
+	r := &EmailResult{Result: newResult(raw), Check: newCheck(raw)}
+	r.IsValid, r.known = flag(raw, validWords, "valid", "result")
+	r.IsDisposable, _ = flag(raw, map[string]bool{"disposable": true}, "disposable")
+	r.passed = r.IsValid
+	return r
+}
+
+// DisposableResult is the typed result of DisposableMailCheck.
+type DisposableResult struct {
+	Result
+	Check
+	IsDisposable bool `json:"is_disposable"`
+}
+
+// NewDisposableResult decodes a DisposableMailCheck response.
+func NewDisposableResult(raw map[string]interface{}) *DisposableResult {
+	r := &DisposableResult{Result: newResult(raw), Check: newCheck(raw)}
+	words := map[string]bool{"disposable": true, "not disposable": false}
+	r.IsDisposable, r.known = flag(raw, words, "disposable", "result")
+	r.passed = !r.IsDisposable
+	return r
+}
+
+// IPValidationResult is the typed result of IPValidator.
+type IPValidationResult struct {
+	Result
+	Check
+	IsValid bool `json:"is_valid"`
+	// Type is the address family, e.g. "IPv4", when the API reports it.
+	Type string `json:"type,omitempty"`
+}
+
+// NewIPValidationResult decodes an IPValidator response.
+func NewIPValidationResult(raw map[string]interface{}) *IPValidationResult {
+	r := &IPValidationResult{Result: newResult(raw), Check: newCheck(raw)}
+	r.IsValid, r.known = flag(raw, validWords, "valid", "result")
+	v, _ := lookup(raw, "type", "version")
+	r.Type = asString(v)
+	r.passed = r.IsValid
+	return r
+}
+
+// TorResult is the typed result of TorChecker.
+type TorResult struct {
+	Result
+	Check
+	IsTorExit bool `json:"is_tor_exit"`
+}
+
+// NewTorResult decodes a TorChecker response.
+func NewTorResult(raw map[string]interface{}) *TorResult {
+	r := &TorResult{Result: newResult(raw), Check: newCheck(raw)}
+	r.IsTorExit, r.known = flag(raw, foundWords, "tor", "result")
+	r.passed = !r.IsTorExit
+	return r
+}
+
+// ProxyResult is the typed result of ProxyDetector.
+type ProxyResult struct {
+	Result
+	Check
+	IsProxy bool `json:"is_proxy"`
+	// Type is the kind of proxy, e.g. "VPN", when the API reports it.
+	Type string `json:"type,omitempty"`
+}
+
+// NewProxyResult decodes a ProxyDetector response.
+func NewProxyResult(raw map[string]interface{}) *ProxyResult {
+	r := &ProxyResult{Result: newResult(raw), Check: newCheck(raw)}
+	r.IsProxy, r.known = flag(raw, foundWords, "proxy", "result")
+	v, _ := lookup(raw, "type", "proxy_type")
+	r.Type = asString(v)
+	r.passed = !r.IsProxy
+	return r
+}
+
+// ReputationResult is the typed result of SiteReputationChecker.
+type ReputationResult struct {
+	Result
+	Check
+	IsMalicious bool `json:"is_malicious"`
+	// Score is the risk score, when the API reports one.
+	Score int `json:"score,omitempty"`
+}
+
+// NewReputationResult decodes a SiteReputationChecker response.
+func NewReputationResult(raw map[string]interface{}) *ReputationResult {
+	r := &ReputationResult{Result: newResult(raw), Check: newCheck(raw)}
+	r.IsMalicious, r.known = flag(raw, maliciousWords, "malicious", "result", "status")
+	v, _ := lookup(raw, "score", "risk_score")
+	r.Score, _ = asInt(v)
+	r.passed = !r.IsMalicious
+	return r
+}
+
+// PhoneResult is the typed result of GetPhoneInfo.
+type PhoneResult struct {
+	Result
+	Check
+	IsValid  bool   `json:"is_valid"`
+	Country  string `json:"country,omitempty"`
+	Carrier  string `json:"carrier,omitempty"`
+	LineType string `json:"line_type,omitempty"`
+	// International is the number in international format.
+	International string `json:"international,omitempty"`
+}
+
+// NewPhoneResult decodes a GetPhoneInfo response.
+func NewPhoneResult(raw map[string]interface{}) *PhoneResult {
+	r := &PhoneResult{Result: newResult(raw), Check: newCheck(raw)}
+	r.IsValid, r.known = flag(raw, validWords, "valid", "is_valid")
+	str := func(keys ...string) string {
+		v, _ := lookup(raw, keys...)
+		return asString(v)
+	}
+	r.Country = str("country", "country_name")
+	r.Carrier = str("carrier", "provider")
+	r.LineType = str("line_type", "type")
+	r.International = str("international_format", "international")
+	r.passed = r.IsValid
+	return r
+}
+
+// EmailValidatorTyped is EmailValidator with a typed result.
+func (c *C99) EmailValidatorTyped(ctx context.Context, email string, opts ...CallOption) (*EmailResult, error) {
+	raw, err := c.EmailValidator(ctx, email, opts...)
+	return typed(raw, err, NewEmailResult)
+}
+
+// DisposableMailCheckTyped is DisposableMailCheck with a typed result.
+func (c *C99) DisposableMailCheckTyped(ctx context.Context, email string, opts ...CallOption) (*DisposableResult, error) {
+	raw, err := c.DisposableMailCheck(ctx, email, opts...)
+	return typed(raw, err, NewDisposableResult)
+}
+
+// IPValidatorTyped is IPValidator with a typed result.
+func (c *C99) IPValidatorTyped(ctx context.Context, ip string, opts ...CallOption) (*IPValidationResult, error) {
+	raw, err := c.IPValidator(ctx, ip, opts...)
+	return typed(raw, err, NewIPValidationResult)
+}
+
+// TorCheckerTyped is TorChecker with a typed result.
+func (c *C99) TorCheckerTyped(ctx context.Context, ip string, opts ...CallOption) (*TorResult, error) {
+	raw, err := c.TorChecker(ctx, ip, opts...)
+	return typed(raw, err, NewTorResult)
+}
+
+// ProxyDetectorTyped is ProxyDetector with a typed result.
+func (c *C99) ProxyDetectorTyped(ctx context.Context, ip string, opts ...CallOption) (*ProxyResult, error) {
+	raw, err := c.ProxyDetector(ctx, ip, opts...)
+	return typed(raw, err, NewProxyResult)
+}
+
+// SiteReputationCheckerTyped is SiteReputationChecker with a typed result.
+func (c *C99) SiteReputationCheckerTyped(ctx context.Context, url string, opts ...CallOption) (*ReputationResult, error) {
+	raw, err := c.SiteReputationChecker(ctx, url, opts...)
+	return typed(raw, err, NewReputationResult)
+}
+
+// GetPhoneInfoTyped is GetPhoneInfo with a typed result.
+func (c *C99) GetPhoneInfoTyped(ctx context.Context, number string, opts ...CallOption) (*PhoneResult, error) {
+	raw, err := c.GetPhoneInfo(ctx, number, opts...)
+	return typed(raw, err, NewPhoneResult)
+}
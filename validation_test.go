@@ -0,0 +1,69 @@
+package c99
+
+import "testing"
+
+func TestVerdicts(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		decode func(map[string]interface{}) Verdict
+		raw    string
+		passed bool
+		known  bool
+		reason string
+	}{
+		{"email valid", emailVerdict, `{"success":true,"result":"valid"}`, true, true, ""},
+		{"email invalid", emailVerdict, `{"success":true,"valid":"false","reason":"no MX record"}`, false, true, "no MX record"},
+		{"email silent", emailVerdict, `{"success":true}`, false, false, ""},
+		{"disposable", disposableVerdict, `{"success":true,"result":"Disposable"}`, false, true, ""},
+		{"not disposable", disposableVerdict, `{"success":true,"disposable":0}`, true, true, ""},
+		{"ip valid", ipValidVerdict, `{"success":true,"result":true,"type":"IPv4"}`, true, true, ""},
+		{"ip unknown word", ipValidVerdict, `{"success":true,"result":"perhaps"}`, false, false, ""},
+		{"tor exit", torVerdict, `{"success":true,"result":"detected"}`, false, true, ""},
+		{"not tor", torVerdict, `{"success":true,"tor":"not found"}`, true, true, ""},
+		{"proxy", proxyVerdict, `{"success":true,"proxy":"yes","type":"VPN"}`, false, true, ""},
+		{"phishing", reputationVerdict, `{"success":true,"result":"Phishing","message":"listed"}`, false, true, "listed"},
+		{"clean", reputationVerdict, `{"success":true,"status":"clean"}`, true, true, ""},
+		{"phone valid", phoneVerdict, `{"success":true,"is_valid":"1"}`, true, true, ""},
+	} {
+		v := tc.decode(decodeRaw(t, tc.raw))
+		passed, known := v.Passed()
+		if passed != tc.passed || known != tc.known || v.Explanation() != tc.reason {
+			t.Errorf("%s: Passed() = %v, %v, Explanation() = %q; want %v, %v, %q",
+				tc.name, passed, known, v.Explanation(), tc.passed, tc.known, tc.reason)
+		}
+	}
+}
+
+func emailVerdict(raw map[string]interface{}) Verdict      { return NewEmailResult(raw) }
+func disposableVerdict(raw map[string]interface{}) Verdict { return NewDisposableResult(raw) }
+func ipValidVerdict(raw map[string]interface{}) Verdict    { return NewIPValidationResult(raw) }
+func torVerdict(raw map[string]interface{}) Verdict        { return NewTorResult(raw) }
+func proxyVerdict(raw map[string]interface{}) Verdict      { return NewProxyResult(raw) }
+func reputationVerdict(raw map[string]interface{}) Verdict { return NewReputationResult(raw) }
+func phoneVerdict(raw map[string]interface{}) Verdict      { return NewPhoneResult(raw) }
+
+func TestValidationFields(t *testing.T) {
+	e := NewEmailResult(decodeRaw(t, `{"success":true,"result":"valid","disposable":"true"}`))
+	if !e.IsValid || !e.IsDisposable {
+		t.Errorf("EmailResult = %+v", e)
+	}
+	ip := NewIPValidationResult(decodeRaw(t, `{"success":true,"valid":true,"version":6}`))
+	if !ip.IsValid || ip.Type != "6" {
+		t.Errorf("IPValidationResult = %+v", ip)
+	}
+	p := NewProxyResult(decodeRaw(t, `{"success":true,"result":"not detected","proxy_type":"none"}`))
+	if p.IsProxy || p.Type != "none" {
+		t.Errorf("ProxyResult = %+v", p)
+	}
+	r := NewReputationResult(decodeRaw(t, `{"success":true,"malicious":false,"risk_score":"12"}`))
+	if r.IsMalicious || r.Score != 12 {
+		t.Errorf("ReputationResult = %+v", r)
+	}
+	ph := NewPhoneResult(decodeRaw(t, `{"success":true,"valid":true,"country_name":"Netherlands",
+		"provider":"KPN","type":"mobile","international_format":"+31 6 12345678"}`))
+	want := PhoneResult{IsValid: true, Country: "Netherlands", Carrier: "KPN", LineType: "mobile", International: "+31 6 12345678"}
+	if ph.IsValid != want.IsValid || ph.Country != want.Country || ph.Carrier != want.Carrier ||
+		ph.LineType != want.LineType || ph.International != want.International {
+		t.Errorf("PhoneResult = %+v", ph)
+	}
+}
@@ -0,0 +1,256 @@
+package c99
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// WhoisRecord holds the fields parsed from WHOIS text. Fields the text
+// does not contain are left at their zero value.
+type WhoisRecord struct {
+	Domain    string    `json:"domain,omitempty"`
+	Registrar string    `json:"registrar,omitempty"`
+	Created   time.Time `json:"created,omitzero"`
+	Updated   time.Time `json:"updated,omitzero"`
+	Expires   time.Time `json:"expires,omitzero"`
+	// Status holds the status codes, such as "clientTransferProhibited",
+	// without the explanatory URLs registries append to them.
+	Status []string `json:"status,omitempty"`
+	// NameServers are lower case, without a trailing dot.
+	NameServers   []string `json:"name_servers,omitempty"`
+	RegistrantOrg string   `json:"registrant_org,omitempty"`
+	AbuseEmail    string   `json:"abuse_email,omitempty"`
+	AbusePhone    string   `json:"abuse_phone,omitempty"`
+	// Text is the WHOIS text the record was parsed from.
+	Text string `json:"text"`
+}
+
+// Field names as they appear across registries, most specific first.
+// Names are compared after whoisKey has normalised them.
+var (
+	whoisDomainKeys    = []string{"domain name", "domain"}
+	whoisRegistrarKeys = []string{"registrar", "registrar name", "sponsoring registrar", "registrar organization"}
+	whoisCreatedKeys   = []string{"creation date", "created", "created on", "created date", "registered on",
+		"registration date", "domain registration date", "registration time", "registered", "registered date", "regdate"}
+	whoisUpdatedKeys = []string{"updated date", "last updated", "last update", "last updated on",
+		"last modified", "modified", "changed", "updated"}
+	whoisExpiresKeys = []string{"registry expiry date", "registrar registration expiration date",
+		"expiration date", "expiry date", "expires", "expires on", "expire date", "expiration time",
+		"paid till", "renewal date"}
+	whoisStatusKeys     = []string{"domain status", "registration status", "status", "state"}
+	whoisNameServerKeys = []string{"name server", "name servers", "nameserver", "nameservers", "nserver",
+		"domain nameservers", "ns"}
+	whoisOrgKeys = []string{"registrant organization", "registrant organisation", "registrant org",
+		"registrant", "owner", "org name", "orgname", "organization", "organisation", "org"}
+	whoisAbuseEmailKeys = []string{"registrar abuse contact email", "abuse contact email", "abuse email",
+		"abuse mailbox", "abuse c email", "orgabuseemail"}
+	whoisAbusePhoneKeys = []string{"registrar abuse contact phone", "abuse contact phone", "abuse phone",
+		"orgabusephone"}
+)
+
+// whoisDateLayouts are tried in order on date fields.
+var whoisDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"02-Jan-2006",
+	"2-Jan-2006",
+	"02.01.2006",
+	"2006.01.02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"20060102",
+	"January 2 2006",
+	"Mon Jan 2 15:04:05 MST 2006",
+}
+
+var (
+	// whoisBracketLine matches JPRS lines such as "a. [Domain Name] EXAMPLE.JP".
+	whoisBracketLine = regexp.MustCompile(`^(?:[a-z]\.\s*)?\[(.+?)\]\s*(.*)$`)
+	// whoisRegistrarTag is the tag Nominet appends to registrar names.
+	whoisRegistrarTag = regexp.MustCompile(`\s*\[Tag = [^\]]*\]$`)
+)
+
+// ParseWhois parses WHOIS text. It understands the "Key: value" lines of
+// ICANN registries, most ccTLDs and the regional internet registries, the
+// indented blocks used by registries such as Nominet ("Name servers:"
+// followed by one server per line) and the "[Key] value" lines of JPRS.
+func ParseWhois(text string) WhoisRecord {
+	fields := whoisFields(text)
+	first := func(keys []string) string {
+		for _, k := range keys {
+			if v := fields[k]; len(v) > 0 {
+				return v[0]
+			}
+		}
+		return ""
+	}
+	all := func(keys []string) []string {
+		for _, k := range keys {
+			if v := fields[k]; len(v) > 0 {
+				return v
+			}
+		}
+		return nil
+	}
+
+	r := WhoisRecord{
+		Domain:        strings.ToLower(first(whoisDomainKeys)),
+		Registrar:     whoisRegistrarTag.ReplaceAllString(first(whoisRegistrarKeys), ""),
+		Created:       parseWhoisDate(first(whoisCreatedKeys)),
+		Updated:       parseWhoisDate(first(whoisUpdatedKeys)),
+		Expires:       parseWhoisDate(first(whoisExpiresKeys)),
+		RegistrantOrg: first(whoisOrgKeys),
+		AbuseEmail:    first(whoisAbuseEmailKeys),
+		AbusePhone:    first(whoisAbusePhoneKeys),
+		Text:          text,
+	}
+	for _, v := range all(whoisStatusKeys) {
+		// "clientDeleteProhibited https://icann.org/epp#...",
+		// "REGISTERED, DELEGATED" or "Registered until expiry date.".
+		for _, s := range strings.Split(v, ",") {
+			if status := whoisStatus(s); status != "" {
+				r.Status = appendUnique(r.Status, status)
+			}
+		}
+	}
+	for _, v := range all(whoisNameServerKeys) {
+		// Some registries follow the name with its addresses.
+		if f := strings.Fields(v); len(f) > 0 {
+			ns := strings.TrimSuffix(strings.ToLower(f[0]), ".")
+			if strings.Contains(ns, ".") {
+				r.NameServers = appendUnique(r.NameServers, ns)
+			}
+		}
+	}
+	return r
+}
+
+// whoisStatus trims a status of the URL or comment that follows it, and
+// of a final full stop.
+func whoisStatus(s string) string {
+	var words []string
+	for _, w := range strings.Fields(s) {
+		if strings.HasPrefix(w, "http") || strings.HasPrefix(w, "(") {
+			break
+		}
+		words = append(words, w)
+	}
+	return strings.TrimSuffix(strings.Join(words, " "), ".")
+}
+
+// whoisFields collects the values of each field in text, by normalised
+// field name and in order of appearance.
+func whoisFields(text string) map[string][]string {
+	fields := make(map[string][]string)
+	add := func(key, value string) {
+		if value != "" {
+			fields[key] = append(fields[key], value)
+		}
+	}
+	block, blockIndent := "", 0
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		trimmed := strings.TrimLeft(line, " \t")
+		indent := len(line) - len(trimmed)
+		if trimmed == "" || strings.HasPrefix(trimmed, "%") || strings.HasPrefix(trimmed, "#") ||
+			strings.HasPrefix(trimmed, ">>>") {
+			block = ""
+			continue
+		}
+		if block != "" && indent <= blockIndent {
+			block = ""
+		}
+
+		if m := whoisBracketLine.FindStringSubmatch(trimmed); m != nil {
+			add(whoisKey(m[1]), strings.TrimSpace(m[2]))
+			continue
+		}
+		key, value, ok := splitWhoisLine(trimmed)
+		switch {
+		case ok && value == "":
+			// The values follow on indented lines.
+			block, blockIndent = key, indent
+		case ok:
+			add(key, value)
+		case block != "":
+			add(block, trimmed)
+		}
+	}
+	return fields
+}
+
+// splitWhoisLine splits "Key: value". The colon must end the line or be
+// followed by a space, so that times and URLs are not split.
+func splitWhoisLine(line string) (key, value string, ok bool) {
+	for i := 0; i < len(line); i++ {
+		if line[i] == ':' && (i+1 == len(line) || line[i+1] == ' ' || line[i+1] == '\t') {
+			key = whoisKey(line[:i])
+			return key, strings.TrimSpace(line[i+1:]), key != ""
+		}
+	}
+	return "", "", false
+}
+
+// whoisKey normalises a field name: "Registrar-Abuse_Contact  Email"
+// becomes "registrar abuse contact email".
+func whoisKey(name string) string {
+	name = strings.NewReplacer("-", " ", "_", " ", ".", " ").Replace(strings.ToLower(name))
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// parseWhoisDate parses a date in any of whoisDateLayouts, ignoring
+// trailing comments such as "20010322 #12345" or "2001/03/22 (JST)". It
+// returns the zero time if the date is not understood.
+func parseWhoisDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, "#("); i > 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	candidates := []string{s}
+	if f := strings.Fields(s); len(f) > 1 {
+		candidates = append(candidates, f[0])
+	}
+	for _, c := range candidates {
+		for _, layout := range whoisDateLayouts {
+			if t, err := time.Parse(layout, c); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, e := range list {
+		if e == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// WhoisResult is the typed result of WhoisChecker.
+type WhoisResult struct {
+	Result
+	WhoisRecord
+}
+
+// NewWhoisResult decodes a WhoisChecker response.
+func NewWhoisResult(raw map[string]interface{}) *WhoisResult {
+	v, _ := lookup(raw, "result", "whois", "data")
+	text, _ := v.(string)
+	return &WhoisResult{Result: newResult(raw), WhoisRecord: ParseWhois(text)}
+}
+
+// WhoisCheckerTyped is WhoisChecker with a typed result.
+func (c *C99) WhoisCheckerTyped(ctx context.Context, domain string, opts ...CallOption) (*WhoisResult, error) {
+	raw, err := c.WhoisChecker(ctx, domain, opts...)
+	return typed(raw, err, NewWhoisResult)
+}
@@ -0,0 +1,227 @@
+package c99
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// A .com record as returned by a registrar's WHOIS server.
+const whoisCom = `Domain Name: google.com
+Registry Domain ID: 2138514_DOMAIN_COM-VRSN
+Registrar WHOIS Server: whois.markmonitor.com
+Registrar URL: http://www.markmonitor.com
+Updated Date: 2019-09-09T15:39:04+0000
+Creation Date: 1997-09-15T07:00:00+0000
+Registrar Registration Expiration Date: 2028-09-13T07:00:00+0000
+Registrar: MarkMonitor, Inc.
+Registrar IANA ID: 292
+Registrar Abuse Contact Email: abusecomplaints@markmonitor.com
+Registrar Abuse Contact Phone: +1.2086851750
+Domain Status: clientUpdateProhibited (https://www.icann.org/epp#clientUpdateProhibited)
+Domain Status: clientTransferProhibited https://icann.org/epp#clientTransferProhibited
+Domain Status: clientTransferProhibited https://icann.org/epp#clientTransferProhibited
+Registrant Organization: Google LLC
+Registrant State/Province: CA
+Registrant Country: US
+Name Server: NS1.GOOGLE.COM.
+Name Server: ns2.google.com
+DNSSEC: unsigned
+URL of the ICANN WHOIS Data Problem Reporting System: http://wdprs.internic.net/
+>>> Last update of WHOIS database: 2024-09-01T12:00:00+0000 <<<
+
+For more information on WHOIS status codes, please visit:
+  https://www.icann.org/resources/pages/epp-status-codes
+`
+
+// A Nominet .uk record, with values indented below their field names.
+const whoisUK = `
+    Domain name:
+        bbc.co.uk
+
+    Data validation:
+        Nominet was able to match the registrant's name and address against a 3rd party data source on 10-Dec-2012
+
+    Registrar:
+        British Broadcasting Corporation [Tag = BBC]
+        URL: http://www.bbc.co.uk
+
+    Relevant dates:
+        Registered on: before Aug-1996
+        Expiry date:  13-Dec-2025
+        Last updated:  11-Nov-2024
+
+    Registration status:
+        Registered until expiry date.
+
+    Name servers:
+        dns0.bbc.co.uk            198.51.100.10
+        dns1.bbc.co.uk
+
+    WHOIS lookup made at 12:00:00 01-Sep-2024
+
+-- 
+This WHOIS information is provided for free by Nominet UK the central registry
+for .uk domain names.
+`
+
+// A RIPE inetnum with its organisation, as returned for an IP address.
+const whoisRIPE = `% This is the RIPE Database query service.
+% The objects are in RPSL format.
+%
+% Information related to '193.0.0.0 - 193.0.7.255'
+
+% Abuse contact for '193.0.0.0 - 193.0.7.255' is 'abuse@ripe.net'
+
+inetnum:        193.0.0.0 - 193.0.7.255
+netname:        RIPE-NCC
+descr:          RIPE Network Coordination Centre
+org:            ORG-RIEN1-RIPE
+country:        NL
+status:         ASSIGNED PA
+mnt-by:         RIPE-NCC-MNT
+created:        2003-03-17T12:15:57Z
+last-modified:  2017-12-04T14:42:31Z
+source:         RIPE # Filtered
+
+organisation:   ORG-RIEN1-RIPE
+org-name:       Reseaux IP Europeens Network Coordination Centre (RIPE NCC)
+org-type:       OTHER
+abuse-mailbox:  abuse@ripe.net
+`
+
+// An ARIN network with its organisation.
+const whoisARIN = `#
+# ARIN WHOIS data and services are subject to the Terms of Use
+# available at: https://www.arin.net/resources/registry/whois/tou/
+#
+
+NetRange:       8.8.8.0 - 8.8.8.255
+CIDR:           8.8.8.0/24
+NetName:        GOGL
+NetType:        Direct Allocation
+Organization:   Google LLC (GOGL)
+RegDate:        2014-03-14
+Updated:        2014-03-14
+Ref:            https://rdap.arin.net/registry/ip/8.8.8.0
+
+OrgName:        Google LLC
+OrgId:          GOGL
+Country:        US
+RegDate:        2000-03-30
+Updated:        2019-10-31
+
+OrgAbuseHandle: ABUSE5250-ARIN
+OrgAbuseName:   Abuse
+OrgAbusePhone:  +1-650-253-0000
+OrgAbuseEmail:  network-abuse@google.com
+`
+
+// A JPRS .jp record, with "[Key] value" lines.
+const whoisJP = `[ JPRS database provides information on network administration. ]
+
+Domain Information:
+a. [Domain Name]                EXAMPLE.JP
+g. [Organization]               Example Corp.
+p. [Name Server]                ns1.example.jp
+[Name Server]                   ns2.example.jp
+[State]                         Connected (2025/03/31)
+[Registered Date]               2001/03/01
+[Last Update]                   2024/04/01 01:05:11 (JST)
+`
+
+func date(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseWhois(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		text string
+		want WhoisRecord
+	}{
+		{"com", whoisCom, WhoisRecord{
+			Domain:        "google.com",
+			Registrar:     "MarkMonitor, Inc.",
+			Created:       date("1997-09-15T07:00:00Z"),
+			Updated:       date("2019-09-09T15:39:04Z"),
+			Expires:       date("2028-09-13T07:00:00Z"),
+			Status:        []string{"clientUpdateProhibited", "clientTransferProhibited"},
+			NameServers:   []string{"ns1.google.com", "ns2.google.com"},
+			RegistrantOrg: "Google LLC",
+			AbuseEmail:    "abusecomplaints@markmonitor.com",
+			AbusePhone:    "+1.2086851750",
+		}},
+		{"uk", whoisUK, WhoisRecord{
+			Domain:      "bbc.co.uk",
+			Registrar:   "British Broadcasting Corporation",
+			Updated:     date("2024-11-11T00:00:00Z"),
+			Expires:     date("2025-12-13T00:00:00Z"),
+			Status:      []string{"Registered until expiry date"},
+			NameServers: []string{"dns0.bbc.co.uk", "dns1.bbc.co.uk"},
+		}},
+		{"ripe", whoisRIPE, WhoisRecord{
+			Created:       date("2003-03-17T12:15:57Z"),
+			Updated:       date("2017-12-04T14:42:31Z"),
+			Status:        []string{"ASSIGNED PA"},
+			RegistrantOrg: "Reseaux IP Europeens Network Coordination Centre (RIPE NCC)",
+			AbuseEmail:    "abuse@ripe.net",
+		}},
+		{"arin", whoisARIN, WhoisRecord{
+			Created:       date("2014-03-14T00:00:00Z"),
+			Updated:       date("2014-03-14T00:00:00Z"),
+			RegistrantOrg: "Google LLC",
+			AbuseEmail:    "network-abuse@google.com",
+			AbusePhone:    "+1-650-253-0000",
+		}},
+		{"jp", whoisJP, WhoisRecord{
+			Domain:        "example.jp",
+			Created:       date("2001-03-01T00:00:00Z"),
+			Updated:       date("2024-04-01T01:05:11Z"),
+			Status:        []string{"Connected"},
+			NameServers:   []string{"ns1.example.jp", "ns2.example.jp"},
+			RegistrantOrg: "Example Corp.",
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ParseWhois(tc.text)
+			if got.Text != tc.text {
+				t.Errorf("Text not kept")
+			}
+			for _, d := range []struct {
+				field     string
+				got, want *time.Time
+			}{
+				{"Created", &got.Created, &tc.want.Created},
+				{"Updated", &got.Updated, &tc.want.Updated},
+				{"Expires", &got.Expires, &tc.want.Expires},
+			} {
+				if !d.got.Equal(*d.want) {
+					t.Errorf("%s = %v, want %v", d.field, *d.got, *d.want)
+				}
+				*d.got, *d.want = time.Time{}, time.Time{}
+			}
+			got.Text = ""
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewWhoisResult(t *testing.T) {
+	for _, key := range []string{"result", "whois", "data"} {
+		raw := map[string]interface{}{"success": true, key: whoisCom}
+		r := NewWhoisResult(raw)
+		if !r.Success || r.Domain != "google.com" || r.Text != whoisCom {
+			t.Errorf("%s: got %+v", key, r.WhoisRecord)
+		}
+	}
+	if r := NewWhoisResult(map[string]interface{}{"success": false, "error": "Invalid domain"}); r.Error != "Invalid domain" || r.Domain != "" {
+		t.Errorf("failure: got %+v", r)
+	}
+}